@@ -1,10 +1,23 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"VK_app/internal/config"
 	l "VK_app/internal/dbconn"
-	h "VK_app/pkg/handlers"
+	"VK_app/internal/migrate"
+	"VK_app/internal/server"
+	"VK_app/pkg/auth"
+	"VK_app/pkg/handlers"
+	"VK_app/pkg/notify"
+	"VK_app/pkg/postgresql"
+	"VK_app/pkg/repository"
 
 	logger "VK_app/pkg/logger"
 
@@ -19,7 +32,6 @@ import (
 
 //swag init -g cmd/main.go --parseDependency --parseInternal -d ./,internal/structures,pkg/handlers && go run cmd/main.go - to start
 
-
 // @title VK Film library
 // @version 1.0
 // @description VK_app_film_library project
@@ -34,52 +46,219 @@ import (
 // @name Authorization
 // @security AdminKeyAuth
 
-func init() {
-	l.Db = l.Connection()
-}
-
 // main is the entry point of the program.
 //
-// It initializes the database connection, sets up the logger, and configures the HTTP router.
-// The main function then starts the HTTP server and listens for incoming requests.
-// It returns an error if there is an issue starting the server.
+// It loads the configuration, sets up the logger and the database connection, and configures the HTTP router.
+// The main function then starts the HTTP server and listens for incoming requests until SIGINT or SIGTERM,
+// when it stops accepting connections, lets the requests in flight complete and closes the database and the log.
+// With --print-config it only prints the effective configuration, secrets redacted, and exits,
+// "migrate" runs the schema migrations instead of the server, see migrateCommand.
 func main() {
-	defer l.Db.Close()
-	logger.LogFile = logger.LoggerInit()
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML configuration file")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration and exit")
+	flag.Parse()
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %s\n", err.Error())
+	}
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if flag.Arg(0) == "migrate" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := migrateCommand(ctx, cfg.Database, flag.Args()[1:], os.Stdout)
+		stop()
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	logger.LogFile, err = logger.LoggerInit(cfg.Log.Level, cfg.Log.Format, cfg.Log.Output)
+	if err != nil {
+		log.Fatalf("Failed to set up the log: %s\n", err.Error())
+	}
 	defer logger.LogFile.Close()
-	log.SetOutput(logger.LogFile)
-	swaggerRouter := gin.Default()
-	swaggerRouter.Use(gin.Logger())
-	swaggerRouter.Use(gin.Logger())
-	swaggerRouter.Use(gin.Recovery())
+	gin.DefaultWriter = logger.LogFile
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// A second signal kills the process without waiting for the requests in flight.
+	context.AfterFunc(ctx, stop)
+	l.Db, err = l.Connection(ctx, cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to the database: %s\n", err.Error())
+	}
+	keys, err := loadKeys(cfg.Auth)
+	if err != nil {
+		log.Fatalf("Failed to load signing keys: %s\n", err.Error())
+	}
+	notifier, err := notify.New(cfg.Notifier.Kind, cfg.Notifier.File)
+	if err != nil {
+		log.Fatalf("Failed to set up the notifier: %s\n", err.Error())
+	}
+	storage := postgresql.New(l.Db)
+	migrator, err := migrate.New(l.Db)
+	if err != nil {
+		log.Fatalf("Failed to load the migrations: %s\n", err.Error())
+	}
+	h := handlers.New(handlers.Repositories{
+		Films:           storage,
+		Actors:          storage,
+		Genres:          storage,
+		Reviews:         storage,
+		Users:           storage,
+		Roles:           storage,
+		Sessions:        storage,
+		Resets:          storage,
+		Watchlists:      storage,
+		Collections:     storage,
+		Recommendations: storage,
+	}, keys, auth.Lifetimes{
+		Access:  time.Duration(cfg.Auth.AccessTokenTTL),
+		Refresh: time.Duration(cfg.Auth.RefreshTokenTTL),
+		Reset:   time.Duration(cfg.Auth.ResetTokenTTL),
+	}, notifier)
+	authenticator := middle.NewAuthenticator(keys, storage)
+	authorizer := middle.NewAuthorizer(storage)
+	canRead := authorizer.Require(repository.PermFilmsRead)
+	canReview := authorizer.Require(repository.PermReviewsWrite)
+	canEdit := authorizer.Require(repository.PermCatalogWrite)
+	canModerate := authorizer.Require(repository.PermReviewsModerate)
+	canManageUsers := authorizer.Require(repository.PermUsersManage)
+
+	health := handlers.NewHealth(
+		handlers.Check{Name: "database", Run: l.Db.PingContext},
+		handlers.Check{Name: "migrations", Run: migrator.Check},
+		handlers.Check{Name: "files", Run: func(context.Context) error { return cfg.CheckFiles() }},
+	)
 
+	swaggerRouter := gin.New()
+	// Only the configured proxies may set the client address, the login throttle counts failures by it.
+	if err := swaggerRouter.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Failed to set the trusted proxies: %s\n", err.Error())
+	}
+	// The probes run every few seconds, logging them would bury the requests.
+	swaggerRouter.Use(gin.LoggerWithConfig(gin.LoggerConfig{SkipPaths: []string{"/healthz", "/readyz"}}), gin.Recovery())
+	swaggerRouter.Use(middle.Deadline(time.Duration(cfg.Server.RequestTimeout)))
+	if len(cfg.CORS.AllowedOrigins) > 0 {
+		swaggerRouter.Use(middle.NewCORS(cfg.CORS.AllowedOrigins, cfg.CORS.AllowCredentials, time.Duration(cfg.CORS.MaxAge)).Handle)
+	}
+
+	swaggerRouter.GET("/healthz", health.Healthz)
+	swaggerRouter.GET("/readyz", health.Readyz)
 	swaggerRouter.POST("/filmlibrary/registration", h.RegisterUser)
 	swaggerRouter.POST("/filmlibrary/login", h.Login)
+	swaggerRouter.POST("/filmlibrary/refresh", h.Refresh)
+	swaggerRouter.GET("/.well-known/jwks.json", h.JWKS)
+	swaggerRouter.POST("/filmlibrary/password/reset/request", h.RequestPasswordReset)
+	swaggerRouter.POST("/filmlibrary/password/reset", h.ResetPassword)
 
 	UserGroup := swaggerRouter.Group("/filmlibrary")
-	UserGroup.Use(middle.CheckToken)
-	UserGroup.POST("/filmssorted", h.GetSortedFilms)
-	UserGroup.POST("/filmspiece", h.GetFilmByPiece)
-	UserGroup.GET("/actors", h.GetAllActors)
+	UserGroup.Use(authenticator.CheckToken)
+	UserGroup.POST("/logout", h.Logout)
+	UserGroup.PUT("/password", h.ChangePassword)
+	UserGroup.GET("/me", h.GetMe)
+	UserGroup.PATCH("/me", h.UpdateMe)
+	UserGroup.DELETE("/me", h.DeleteMe)
+	UserGroup.GET("/films", canRead, h.ListFilms)
+	UserGroup.GET("/films/:id", canRead, h.GetFilm)
+	UserGroup.GET("/films/:id/similar", canRead, h.GetSimilarFilms)
+	UserGroup.GET("/recommendations", canRead, h.GetRecommendations)
+	UserGroup.POST("/filmssorted", canRead, h.GetSortedFilms)
+	UserGroup.POST("/filmspiece", canRead, h.GetFilmByPiece)
+	UserGroup.GET("/actors", canRead, h.GetAllActors)
+	UserGroup.GET("/actors/:id", canRead, h.GetActor)
+	UserGroup.GET("/search", canRead, h.Search)
+	UserGroup.GET("/search/films", canRead, h.SearchFilms)
+	UserGroup.GET("/search/actors", canRead, h.SearchActors)
+	UserGroup.GET("/genres", canRead, h.GetGenres)
+	UserGroup.GET("/reviews", canRead, h.GetFilmReviews)
+	UserGroup.PUT("/reviews", canReview, h.PutReview)
+	UserGroup.DELETE("/reviews", canReview, h.DeleteReview)
+	UserGroup.GET("/watchlist", canRead, h.GetWatchlist)
+	UserGroup.POST("/watchlist", canRead, h.PostWatchlist)
+	UserGroup.DELETE("/watchlist", canRead, h.DeleteWatchlist)
+	UserGroup.GET("/watched", canRead, h.GetWatchHistory)
+	UserGroup.POST("/watched", canRead, h.PostWatched)
+	UserGroup.DELETE("/watched", canRead, h.DeleteWatched)
+	UserGroup.GET("/me/collections", canRead, h.GetMyCollections)
+	UserGroup.GET("/collections", canRead, h.ListCollections)
+	UserGroup.POST("/collections", canRead, h.PostCollection)
+	UserGroup.GET("/collections/:slug", canRead, h.GetCollection)
+	UserGroup.PATCH("/collections/:slug", canRead, h.UpdateCollection)
+	UserGroup.DELETE("/collections/:slug", canRead, h.DeleteCollection)
+	UserGroup.POST("/collections/:slug/films", canRead, h.PostCollectionFilm)
+	UserGroup.DELETE("/collections/:slug/films", canRead, h.DeleteCollectionFilm)
+	UserGroup.PUT("/collections/:slug/order", canRead, h.ReorderCollection)
 
 	AdminGroup := swaggerRouter.Group("/filmlibrary/admin")
-	AdminGroup.Use(middle.CheckTokenAdmin)
-	AdminGroup.DELETE("/film", h.DeleteFilm)
-	AdminGroup.PUT("/film", h.UpdateFilm)
-	AdminGroup.DELETE("/actor", h.DeleteActor)
-	AdminGroup.PUT("/actor", h.UpdateActor)
-	AdminGroup.POST("/actors", h.PostActor)
-	AdminGroup.POST("/films", h.PostFilm)
-	AdminGroup.POST("/filmssorted", h.GetSortedFilmsAdmin)
-	AdminGroup.POST("/filmspiece", h.GetFilmByPieceAdmin)
-	AdminGroup.GET("/actors", h.GetAllActorsAdmin)
-	AdminGroup.POST("/actorsfilms", h.PostActorFilm)
+	AdminGroup.Use(authenticator.CheckToken)
+	AdminGroup.DELETE("/film", canEdit, h.DeleteFilm)
+	AdminGroup.PUT("/film", canEdit, h.UpdateFilm)
+	AdminGroup.DELETE("/actor", canEdit, h.DeleteActor)
+	AdminGroup.PUT("/actor", canEdit, h.UpdateActor)
+	AdminGroup.POST("/actors", canEdit, h.PostActor)
+	AdminGroup.POST("/films", canEdit, h.PostFilm)
+	AdminGroup.GET("/films", canEdit, h.ListFilmsAdmin)
+	AdminGroup.GET("/films/:id", canEdit, h.GetFilmAdmin)
+	AdminGroup.POST("/filmssorted", canEdit, h.GetSortedFilmsAdmin)
+	AdminGroup.POST("/filmspiece", canEdit, h.GetFilmByPieceAdmin)
+	AdminGroup.GET("/actors", canEdit, h.GetAllActorsAdmin)
+	AdminGroup.GET("/actors/:id", canEdit, h.GetActorAdmin)
+	AdminGroup.GET("/search", canEdit, h.SearchAdmin)
+	AdminGroup.GET("/search/films", canEdit, h.SearchFilmsAdmin)
+	AdminGroup.GET("/search/actors", canEdit, h.SearchActorsAdmin)
+	AdminGroup.POST("/actorsfilms", canEdit, h.PostActorFilm)
+	AdminGroup.PUT("/actorsfilms", canEdit, h.UpdateActorFilm)
+	AdminGroup.DELETE("/actorsfilms", canEdit, h.DeleteActorFilm)
+	AdminGroup.GET("/actorsfilms", canEdit, h.GetFilmCredits)
+	AdminGroup.GET("/genres", canEdit, h.GetGenresAdmin)
+	AdminGroup.POST("/genres", canEdit, h.PostGenre)
+	AdminGroup.PUT("/genre", canEdit, h.UpdateGenre)
+	AdminGroup.DELETE("/genre", canEdit, h.DeleteGenre)
+	AdminGroup.POST("/filmsgenres", canEdit, h.PostFilmGenre)
+	AdminGroup.DELETE("/filmsgenres", canEdit, h.DeleteFilmGenre)
+	AdminGroup.GET("/reviews", canModerate, h.GetFilmReviewsAdmin)
+	AdminGroup.DELETE("/review", canModerate, h.DeleteReviewAdmin)
+	AdminGroup.GET("/roles", canManageUsers, h.GetRoles)
+	AdminGroup.PUT("/users/role", canManageUsers, h.SetUserRole)
+	AdminGroup.POST("/users/unlock", canManageUsers, h.UnlockUser)
+	AdminGroup.GET("/users", canManageUsers, h.ListUsers)
+	AdminGroup.POST("/users/disable", canManageUsers, h.DisableUser)
+	AdminGroup.POST("/users/enable", canManageUsers, h.EnableUser)
 
 	swaggerRouter.GET("/docs", func(c *gin.Context) { c.Redirect(http.StatusFound, "swagger/index.html") })
 	swaggerRouter.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	if err := http.ListenAndServe(":8080", swaggerRouter); err != nil {
-		log.Printf("Failed to start server: %s\n", err.Error())
-		return
+	log.Printf("listening on %s\n", cfg.Server.Addr)
+	if err := server.Run(ctx, server.New(cfg.Server, swaggerRouter), cfg.Server); err != nil {
+		log.Printf("Server stopped: %s\n", err.Error())
+	}
+	if err := l.Db.Close(); err != nil {
+		log.Printf("Failed to close the database: %s\n", err.Error())
+	}
+	log.Println("stopped")
+}
+
+// loadKeys loads the configured JWT keys, a lone secret becomes the HS256 key "default".
+// The signing key defaults to the first key.
+//
+// To rotate keys, add the new key, make it the signing one, and drop the old key
+// once the access tokens it signed have expired.
+func loadKeys(cfg config.AuthConfig) (*auth.KeySet, error) {
+	var configs []auth.KeyConfig
+	for _, k := range cfg.Keys {
+		configs = append(configs, auth.KeyConfig{ID: k.ID, Algorithm: k.Algorithm, File: k.File})
+	}
+	if len(configs) == 0 && cfg.Secret != "" {
+		configs = append(configs, auth.KeyConfig{ID: "default", Algorithm: auth.HS256, Secret: cfg.Secret})
+	}
+	signingID := cfg.SigningKey
+	if signingID == "" && len(configs) > 0 {
+		signingID = configs[0].ID
 	}
+	return auth.LoadKeySet(configs, signingID)
 }
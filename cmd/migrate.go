@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"VK_app/internal/config"
+	l "VK_app/internal/dbconn"
+	"VK_app/internal/migrate"
+)
+
+// migrateUsage describes the migrate subcommand.
+const migrateUsage = "usage: main [--config file] migrate up|down|status|redo|seed"
+
+// migrateCommand runs the migrate subcommand against the configured database and reports to w.
+//
+//	up      applies the pending migrations
+//	down    rolls back the latest applied migration
+//	status  lists the migrations and when they were applied
+//	redo    rolls back the latest applied migration and applies it again
+//	seed    loads the sample catalog and users, it can be run repeatedly
+func migrateCommand(ctx context.Context, cfg config.DatabaseConfig, args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+	db, err := l.Connection(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	m, err := migrate.New(db)
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, migration := range applied {
+			fmt.Fprintf(w, "applied %s\n", migration)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(w, "schema is up to date")
+		}
+		return err
+	case "down":
+		migration, err := m.Down(ctx)
+		if errors.Is(err, migrate.ErrNoChange) {
+			fmt.Fprintln(w, "nothing to roll back")
+			return nil
+		}
+		if err == nil {
+			fmt.Fprintf(w, "rolled back %s\n", migration)
+		}
+		return err
+	case "redo":
+		migration, err := m.Redo(ctx)
+		if err == nil {
+			fmt.Fprintf(w, "redone %s\n", migration)
+		}
+		return err
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "MIGRATION\tAPPLIED AT")
+		for _, status := range statuses {
+			applied := "pending"
+			if !status.AppliedAt.IsZero() {
+				applied = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(table, "%s\t%s\n", status.Migration, applied)
+		}
+		return table.Flush()
+	case "seed":
+		if err := m.Seed(ctx); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "seeded")
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
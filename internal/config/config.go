@@ -0,0 +1,357 @@
+// Package config loads the configuration of the film library.
+//
+// The configuration starts from the defaults, is read from an optional YAML or TOML file
+// and is then overridden by the environment variables, so that the variables used before
+// the configuration file existed keep working.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the configuration of the application.
+type Config struct {
+	Server   ServerConfig   `yaml:"server" toml:"server"`
+	Database DatabaseConfig `yaml:"database" toml:"database"`
+	Log      LogConfig      `yaml:"log" toml:"log"`
+	Auth     AuthConfig     `yaml:"auth" toml:"auth"`
+	CORS     CORSConfig     `yaml:"cors" toml:"cors"`
+	Notifier NotifierConfig `yaml:"notifier" toml:"notifier"`
+}
+
+// ServerConfig describes the HTTP server. TLS is served when both files are set.
+// RequestTimeout is the deadline of every request, its database queries are cancelled once it passes.
+// Zero disables it, as it does the read, write and idle timeouts of the connections.
+// On shutdown the requests in flight get ShutdownTimeout to complete.
+// TrustedProxies are the addresses or CIDR ranges of the reverse proxies whose X-Forwarded-For
+// gives the client address. None by default, the address of the connection is the client's.
+type ServerConfig struct {
+	Addr            string   `yaml:"addr" toml:"addr"`
+	TLSCertFile     string   `yaml:"tls_cert_file" toml:"tls_cert_file"`
+	TLSKeyFile      string   `yaml:"tls_key_file" toml:"tls_key_file"`
+	RequestTimeout  Duration `yaml:"request_timeout" toml:"request_timeout"`
+	ReadTimeout     Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout    Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout     Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	TrustedProxies  []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+}
+
+// TLS reports whether the server serves HTTPS.
+func (s ServerConfig) TLS() bool {
+	return s.TLSCertFile != "" && s.TLSKeyFile != ""
+}
+
+// DatabaseConfig describes the PostgreSQL connection and its pool.
+// DSN, when set, is used as is, otherwise it is built from the other connection fields.
+// ConnectTimeout bounds the wait for the database at startup, zero waits as long as it takes.
+// StatementTimeout makes PostgreSQL cancel the statements running longer, zero disables it.
+type DatabaseConfig struct {
+	DSN              string   `yaml:"dsn" toml:"dsn"`
+	Host             string   `yaml:"host" toml:"host"`
+	Port             int      `yaml:"port" toml:"port"`
+	User             string   `yaml:"user" toml:"user"`
+	Password         string   `yaml:"password" toml:"password"`
+	Name             string   `yaml:"name" toml:"name"`
+	SSLMode          string   `yaml:"ssl_mode" toml:"ssl_mode"`
+	MaxOpenConns     int      `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns     int      `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime  Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	ConnMaxIdleTime  Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
+	ConnectTimeout   Duration `yaml:"connect_timeout" toml:"connect_timeout"`
+	StatementTimeout Duration `yaml:"statement_timeout" toml:"statement_timeout"`
+}
+
+// ConnectionString returns DSN or, if it is empty, the URL made of the connection fields.
+// The statement timeout is added unless DSN sets one itself.
+func (d DatabaseConfig) ConnectionString() string {
+	timeout := ""
+	if d.StatementTimeout > 0 {
+		timeout = fmt.Sprint(time.Duration(d.StatementTimeout).Milliseconds())
+	}
+	if d.DSN != "" {
+		if timeout == "" || strings.Contains(d.DSN, "statement_timeout") {
+			return d.DSN
+		}
+		if u, err := url.Parse(d.DSN); err == nil && u.Scheme != "" {
+			query := u.Query()
+			query.Set("statement_timeout", timeout)
+			u.RawQuery = query.Encode()
+			return u.String()
+		}
+		return d.DSN + " statement_timeout=" + timeout
+	}
+	query := url.Values{"sslmode": {d.SSLMode}}
+	if timeout != "" {
+		query.Set("statement_timeout", timeout)
+	}
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(d.User, d.Password),
+		Host:     net.JoinHostPort(d.Host, fmt.Sprint(d.Port)),
+		Path:     "/" + d.Name,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+// LogConfig describes the application log. Output is a file path, "stdout" or "stderr".
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level"`
+	Format string `yaml:"format" toml:"format"`
+	Output string `yaml:"output" toml:"output"`
+}
+
+// AuthConfig describes the token lifetimes and the JWT keys.
+// Keys and SigningKey work as described in auth.LoadKeySet, Secret alone sets up a single HS256 key.
+type AuthConfig struct {
+	AccessTokenTTL  Duration    `yaml:"access_token_ttl" toml:"access_token_ttl"`
+	RefreshTokenTTL Duration    `yaml:"refresh_token_ttl" toml:"refresh_token_ttl"`
+	ResetTokenTTL   Duration    `yaml:"reset_token_ttl" toml:"reset_token_ttl"`
+	Keys            []KeyConfig `yaml:"keys" toml:"keys"`
+	SigningKey      string      `yaml:"signing_key" toml:"signing_key"`
+	Secret          string      `yaml:"secret" toml:"secret"`
+}
+
+// KeyConfig describes a JWT key, see auth.KeyConfig.
+type KeyConfig struct {
+	ID        string `yaml:"id" toml:"id"`
+	Algorithm string `yaml:"algorithm" toml:"algorithm"`
+	File      string `yaml:"file" toml:"file"`
+}
+
+// CORSConfig describes the cross-origin requests allowed. No origins disables CORS.
+type CORSConfig struct {
+	AllowedOrigins   []string `yaml:"allowed_origins" toml:"allowed_origins"`
+	AllowCredentials bool     `yaml:"allow_credentials" toml:"allow_credentials"`
+	MaxAge           Duration `yaml:"max_age" toml:"max_age"`
+}
+
+// NotifierConfig describes how messages reach the users: Kind is "log" or "file", File is used by the latter.
+type NotifierConfig struct {
+	Kind string `yaml:"kind" toml:"kind"`
+	File string `yaml:"file" toml:"file"`
+}
+
+// Duration is a time.Duration written as "15m" or "720h" in the files and the environment.
+type Duration time.Duration
+
+// UnmarshalText parses the duration.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText formats the duration.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Default returns the configuration used when nothing overrides it.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			RequestTimeout:  Duration(10 * time.Second),
+			ReadTimeout:     Duration(15 * time.Second),
+			WriteTimeout:    Duration(30 * time.Second),
+			IdleTimeout:     Duration(2 * time.Minute),
+			ShutdownTimeout: Duration(10 * time.Second),
+		},
+		Database: DatabaseConfig{
+			Host:             "localhost",
+			Port:             5432,
+			SSLMode:          "disable",
+			MaxOpenConns:     25,
+			MaxIdleConns:     5,
+			ConnMaxLifetime:  Duration(30 * time.Minute),
+			ConnMaxIdleTime:  Duration(5 * time.Minute),
+			ConnectTimeout:   Duration(time.Minute),
+			StatementTimeout: Duration(30 * time.Second),
+		},
+		Log: LogConfig{Level: "info", Format: "text", Output: "logger.txt"},
+		Auth: AuthConfig{
+			AccessTokenTTL:  Duration(15 * time.Minute),
+			RefreshTokenTTL: Duration(30 * 24 * time.Hour),
+			ResetTokenTTL:   Duration(30 * time.Minute),
+		},
+		CORS:     CORSConfig{MaxAge: Duration(12 * time.Hour)},
+		Notifier: NotifierConfig{Kind: "log", File: "notifications.txt"},
+	}
+}
+
+// Load returns the defaults overridden by the file at path, if path is not empty,
+// and then by the environment. The result is validated.
+func Load(path string) (Config, error) {
+	c := Default()
+	if path != "" {
+		if err := c.readFile(path); err != nil {
+			return c, fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+	if err := c.applyEnv(os.Getenv); err != nil {
+		return c, err
+	}
+	return c, c.Validate()
+}
+
+// readFile decodes the file over the configuration, the extension picks YAML or TOML.
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return yaml.Unmarshal(data, c)
+	case ".toml":
+		return toml.Unmarshal(data, c)
+	default:
+		return errors.New("unknown format, use .yaml, .yml or .toml")
+	}
+}
+
+// Validate checks the configuration and reports every problem found.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	check(c.Server.Addr != "", "server.addr is empty")
+	check((c.Server.TLSCertFile == "") == (c.Server.TLSKeyFile == ""), "server.tls_cert_file and server.tls_key_file must be set together")
+	s := c.Server
+	check(s.RequestTimeout >= 0 && s.ReadTimeout >= 0 && s.WriteTimeout >= 0 && s.IdleTimeout >= 0, "server timeouts must not be negative")
+	check(s.WriteTimeout == 0 || s.WriteTimeout > s.RequestTimeout, "server.write_timeout must exceed server.request_timeout")
+	check(s.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	for _, proxy := range s.TrustedProxies {
+		_, _, err := net.ParseCIDR(proxy)
+		check(err == nil || net.ParseIP(proxy) != nil, "server.trusted_proxies entry %q is not an address or a CIDR range", proxy)
+	}
+
+	d := c.Database
+	if d.DSN == "" {
+		check(d.Host != "" && d.User != "" && d.Name != "", "database.host, database.user and database.name are required without database.dsn")
+		check(d.Port > 0 && d.Port < 65536, "database.port %d is out of range", d.Port)
+		check(oneOf(d.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full"),
+			"database.ssl_mode %q is unknown", d.SSLMode)
+	}
+	check(d.MaxOpenConns >= 0, "database.max_open_conns must not be negative")
+	check(d.MaxIdleConns >= 0, "database.max_idle_conns must not be negative")
+	check(d.MaxOpenConns == 0 || d.MaxIdleConns <= d.MaxOpenConns, "database.max_idle_conns must not exceed database.max_open_conns")
+	check(d.ConnMaxLifetime >= 0 && d.ConnMaxIdleTime >= 0, "database connection lifetimes must not be negative")
+	check(d.ConnectTimeout >= 0 && d.StatementTimeout >= 0, "database timeouts must not be negative")
+
+	check(oneOf(c.Log.Level, "debug", "info"), "log.level %q is not debug or info, the errors are still logged at the info level", c.Log.Level)
+	check(oneOf(c.Log.Format, "text", "json"), "log.format %q is not text or json", c.Log.Format)
+	check(c.Log.Output != "", "log.output is empty")
+
+	a := c.Auth
+	check(a.AccessTokenTTL > 0 && a.RefreshTokenTTL > 0 && a.ResetTokenTTL > 0, "auth token lifetimes must be positive")
+	check(a.AccessTokenTTL < a.RefreshTokenTTL, "auth.access_token_ttl must be shorter than auth.refresh_token_ttl")
+	check(len(a.Keys) > 0 || a.Secret != "", "auth.keys or auth.secret (JWT_KEYS or JWT_SECRET) is required")
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		check(origin != "*" || !c.CORS.AllowCredentials, "cors.allowed_origins can not be * with cors.allow_credentials")
+	}
+	check(c.CORS.MaxAge >= 0, "cors.max_age must not be negative")
+
+	check(oneOf(c.Notifier.Kind, "log", "file"), "notifier.kind %q is not log or file", c.Notifier.Kind)
+	check(c.Notifier.Kind != "file" || c.Notifier.File != "", "notifier.file is required by the file notifier")
+	return errors.Join(errs...)
+}
+
+// CheckFiles reports the key and TLS files that can no longer be read. They are loaded at startup,
+// a file lost or made unreadable since then would only fail the next start.
+func (c Config) CheckFiles() error {
+	var files []string
+	for _, key := range c.Auth.Keys {
+		if key.File != "" {
+			files = append(files, key.File)
+		}
+	}
+	if c.Server.TLS() {
+		files = append(files, c.Server.TLSCertFile, c.Server.TLSKeyFile)
+	}
+	var errs []error
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		f.Close()
+	}
+	return errors.Join(errs...)
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
+
+// redacted replaces the secrets.
+const redacted = "******"
+
+// Redacted returns a copy of the configuration with the passwords and secrets replaced.
+func (c Config) Redacted() Config {
+	if c.Database.Password != "" {
+		c.Database.Password = redacted
+	}
+	if c.Database.DSN != "" {
+		c.Database.DSN = redactDSN(c.Database.DSN)
+	}
+	if c.Auth.Secret != "" {
+		c.Auth.Secret = redacted
+	}
+	return c
+}
+
+// passwordParam matches the password of a "key=value" connection string.
+var passwordParam = regexp.MustCompile(`password=('[^']*'|\S*)`)
+
+// redactDSN replaces the password of a URL or "key=value" connection string.
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		if u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				// url escapes the asterisks, so the password is marked first and replaced after.
+				u.User = url.UserPassword(u.User.Username(), "redacted")
+				return strings.Replace(u.String(), ":redacted@", ":"+redacted+"@", 1)
+			}
+		}
+		return u.String()
+	}
+	return passwordParam.ReplaceAllString(dsn, "password="+redacted)
+}
+
+// Print writes the configuration with its secrets redacted as YAML.
+func (c Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
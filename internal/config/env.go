@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// envVar is an environment variable overriding a setting.
+type envVar struct {
+	name string
+	set  func(c *Config, value string) error
+}
+
+// envVars lists the environment variables in the order they are applied.
+var envVars = []envVar{
+	{"LISTEN_ADDR", func(c *Config, v string) error { c.Server.Addr = v; return nil }},
+	{"TLS_CERT_FILE", func(c *Config, v string) error { c.Server.TLSCertFile = v; return nil }},
+	{"TLS_KEY_FILE", func(c *Config, v string) error { c.Server.TLSKeyFile = v; return nil }},
+	{"REQUEST_TIMEOUT", func(c *Config, v string) error { return c.Server.RequestTimeout.UnmarshalText([]byte(v)) }},
+	{"READ_TIMEOUT", func(c *Config, v string) error { return c.Server.ReadTimeout.UnmarshalText([]byte(v)) }},
+	{"WRITE_TIMEOUT", func(c *Config, v string) error { return c.Server.WriteTimeout.UnmarshalText([]byte(v)) }},
+	{"IDLE_TIMEOUT", func(c *Config, v string) error { return c.Server.IdleTimeout.UnmarshalText([]byte(v)) }},
+	{"SHUTDOWN_TIMEOUT", func(c *Config, v string) error { return c.Server.ShutdownTimeout.UnmarshalText([]byte(v)) }},
+	{"TRUSTED_PROXIES", func(c *Config, v string) error { c.Server.TrustedProxies = splitList(v); return nil }},
+
+	{"DATABASE_URL", func(c *Config, v string) error { c.Database.DSN = v; return nil }},
+	{"POSTGRES_HOST", func(c *Config, v string) error { c.Database.Host = v; return nil }},
+	{"POSTGRES_PORT", func(c *Config, v string) error { return setInt(&c.Database.Port, v) }},
+	{"POSTGRES_USER", func(c *Config, v string) error { c.Database.User = v; return nil }},
+	{"POSTGRES_PASSWORD", func(c *Config, v string) error { c.Database.Password = v; return nil }},
+	{"POSTGRES_DB", func(c *Config, v string) error { c.Database.Name = v; return nil }},
+	{"POSTGRES_SSLMODE", func(c *Config, v string) error { c.Database.SSLMode = v; return nil }},
+	{"DB_MAX_OPEN_CONNS", func(c *Config, v string) error { return setInt(&c.Database.MaxOpenConns, v) }},
+	{"DB_MAX_IDLE_CONNS", func(c *Config, v string) error { return setInt(&c.Database.MaxIdleConns, v) }},
+	{"DB_CONN_MAX_LIFETIME", func(c *Config, v string) error { return c.Database.ConnMaxLifetime.UnmarshalText([]byte(v)) }},
+	{"DB_CONN_MAX_IDLE_TIME", func(c *Config, v string) error { return c.Database.ConnMaxIdleTime.UnmarshalText([]byte(v)) }},
+	{"DB_CONNECT_TIMEOUT", func(c *Config, v string) error { return c.Database.ConnectTimeout.UnmarshalText([]byte(v)) }},
+	{"DB_STATEMENT_TIMEOUT", func(c *Config, v string) error { return c.Database.StatementTimeout.UnmarshalText([]byte(v)) }},
+
+	{"LOG_LEVEL", func(c *Config, v string) error { c.Log.Level = v; return nil }},
+	{"LOG_FORMAT", func(c *Config, v string) error { c.Log.Format = v; return nil }},
+	{"LOG_OUTPUT", func(c *Config, v string) error { c.Log.Output = v; return nil }},
+
+	{"ACCESS_TOKEN_TTL", func(c *Config, v string) error { return c.Auth.AccessTokenTTL.UnmarshalText([]byte(v)) }},
+	{"REFRESH_TOKEN_TTL", func(c *Config, v string) error { return c.Auth.RefreshTokenTTL.UnmarshalText([]byte(v)) }},
+	{"RESET_TOKEN_TTL", func(c *Config, v string) error { return c.Auth.ResetTokenTTL.UnmarshalText([]byte(v)) }},
+	{"JWT_KEYS", func(c *Config, v string) error { return setKeys(&c.Auth.Keys, v) }},
+	{"JWT_SIGNING_KEY", func(c *Config, v string) error { c.Auth.SigningKey = v; return nil }},
+	{"JWT_SECRET", func(c *Config, v string) error { c.Auth.Secret = v; return nil }},
+
+	{"CORS_ALLOWED_ORIGINS", func(c *Config, v string) error { c.CORS.AllowedOrigins = splitList(v); return nil }},
+	{"CORS_ALLOW_CREDENTIALS", func(c *Config, v string) error { return setBool(&c.CORS.AllowCredentials, v) }},
+	{"CORS_MAX_AGE", func(c *Config, v string) error { return c.CORS.MaxAge.UnmarshalText([]byte(v)) }},
+
+	{"NOTIFIER", func(c *Config, v string) error { c.Notifier.Kind = v; return nil }},
+	{"NOTIFIER_FILE", func(c *Config, v string) error { c.Notifier.File = v; return nil }},
+}
+
+// applyEnv overrides the settings whose environment variables are set and not empty.
+func (c *Config) applyEnv(getenv func(string) string) error {
+	for _, v := range envVars {
+		value := getenv(v.name)
+		if value == "" {
+			continue
+		}
+		if err := v.set(c, value); err != nil {
+			return fmt.Errorf("%s: %w", v.name, err)
+		}
+	}
+	return nil
+}
+
+func setInt(field *int, value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	*field = n
+	return nil
+}
+
+func setBool(field *bool, value string) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	*field = b
+	return nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// setKeys parses comma separated "id=algorithm:file" entries.
+func setKeys(keys *[]KeyConfig, value string) error {
+	var parsed []KeyConfig
+	for _, entry := range splitList(value) {
+		id, rest, ok1 := strings.Cut(entry, "=")
+		algorithm, file, ok2 := strings.Cut(rest, ":")
+		if !ok1 || !ok2 {
+			return fmt.Errorf("entry %q is not id=algorithm:file", entry)
+		}
+		parsed = append(parsed, KeyConfig{ID: id, Algorithm: algorithm, File: file})
+	}
+	*keys = parsed
+	return nil
+}
@@ -1,29 +1,62 @@
 package dbconn
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
-	"os"
+	"time"
+
+	"VK_app/internal/config"
 
 	_ "github.com/lib/pq"
 )
 
 var Db *sql.DB
 
-func Connection() *sql.DB {
-	user := os.Getenv("POSTGRES_USER")
-	pass := os.Getenv("POSTGRES_PASSWORD")
-	host := os.Getenv("POSTGRES_HOST")
-	port := os.Getenv("POSTGRES_PORT")
-	dataBase := os.Getenv("POSTGRES_DB")
-
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", user, pass, host, port, dataBase)
+// Backoff bounds of the startup wait for the database.
+const (
+	firstRetry = 500 * time.Millisecond
+	maxRetry   = 10 * time.Second
+)
 
-	db, err := sql.Open("postgres", connStr)
+// Connection opens the connection pool of the configured database, applies its limits and waits
+// until the database answers, retrying with a growing pause for at most cfg.ConnectTimeout.
+func Connection(ctx context.Context, cfg config.DatabaseConfig) (*sql.DB, error) {
+	db, err := sql.Open("postgres", cfg.ConnectionString())
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime))
+	db.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTime))
+	if err := wait(ctx, db, time.Duration(cfg.ConnectTimeout)); err != nil {
+		db.Close()
+		return nil, err
 	}
+	return db, nil
+}
 
-	return db
+// wait pings the database until it answers, the timeout passes or the context is done.
+func wait(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	pause := firstRetry
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		log.Printf("database is not reachable, attempt %d: %v\n", attempt, err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database is not reachable after %d attempts: %w", attempt, err)
+		case <-time.After(pause):
+		}
+		pause = min(2*pause, maxRetry)
+	}
 }
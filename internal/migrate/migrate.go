@@ -0,0 +1,410 @@
+// Package migrate applies the versioned schema migrations of the film library.
+//
+// Migrations are the numbered files embedded from the migrations directory, NNNN_name.up.sql
+// with its NNNN_name.down.sql undoing it. The applied versions are recorded in the schema_migrations
+// table. Every change runs under an advisory lock, so concurrent runners apply each migration once.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var files embed.FS
+
+//go:embed seed.sql
+var seed string
+
+// lockID is the key of the advisory lock the runners take.
+const lockID = 7438305119
+
+// ErrNoChange is returned when there is no migration to roll back.
+var ErrNoChange = errors.New("no migration is applied")
+
+// Migration is a version of the schema.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// String returns the migration as its files are named, 0001_initial_schema.
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Status is a migration along with the time it was applied, zero if it is pending.
+type Status struct {
+	Migration
+	AppliedAt time.Time
+}
+
+// Migrator applies the migrations to a database.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// fileName matches the names of the migration files.
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// New returns a Migrator of the embedded migrations working on the database.
+func New(db *sql.DB) (*Migrator, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// load reads the migrations ordered by version, each must have both its files.
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file %s is not named NNNN_name.up.sql or NNNN_name.down.sql", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		data, err := fs.ReadFile(fsys, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %s needs both an up and a down file", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies the pending migrations in order and returns them.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the latest applied migration and returns it, ErrNoChange if none is applied.
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	var done Migration
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int]time.Time) error {
+		var err error
+		done, err = m.rollback(ctx, conn, applied)
+		return err
+	})
+	return done, err
+}
+
+// Redo rolls back the latest applied migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) (Migration, error) {
+	var done Migration
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int]time.Time) error {
+		var err error
+		if done, err = m.rollback(ctx, conn, applied); err != nil {
+			return err
+		}
+		return m.apply(ctx, conn, done, true)
+	})
+	return done, err
+}
+
+// Status returns every migration along with the time it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.locked(ctx, func(_ *sql.Conn, applied map[int]time.Time) error {
+		for _, migration := range m.migrations {
+			statuses = append(statuses, Status{Migration: migration, AppliedAt: applied[migration.Version]})
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// Check reports the migrations not applied yet. It takes no lock, readiness probes call it.
+func (m *Migrator) Check(ctx context.Context) error {
+	applied, err := appliedVersions(ctx, m.db)
+	if err != nil {
+		return err
+	}
+	pending := 0
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%d of %d migrations are pending", pending, len(m.migrations))
+	}
+	return nil
+}
+
+// Seed loads the sample catalog and users. It requires an up to date schema and can be run repeatedly.
+func (m *Migrator) Seed(ctx context.Context) error {
+	return m.locked(ctx, func(conn *sql.Conn, applied map[int]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; !ok {
+				return fmt.Errorf("migration %s is pending, migrate up first", migration)
+			}
+		}
+		return inTx(ctx, conn, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, seed)
+			return err
+		})
+	})
+}
+
+// locked runs fn on a connection holding the advisory lock, along with the applied versions.
+// It creates the schema_migrations table first and adopts the databases made by init.sql.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, applied map[int]time.Time) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+        version int4 NOT NULL,
+        "name" varchar(100) NOT NULL,
+        applied_at timestamptz DEFAULT now() NOT NULL,
+        CONSTRAINT schema_migrations_pk PRIMARY KEY (version)
+)`); err != nil {
+		return err
+	}
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		if applied, err = m.adopt(ctx, conn); err != nil {
+			return err
+		}
+	}
+	return fn(conn, applied)
+}
+
+// initialSchema is every column of the tables the former init.sql created, as information_schema
+// describes them: the table, the column, its type along with the length and whether it is nullable.
+// A database without schema_migrations is adopted at version 1 only if its tables look exactly like this.
+var initialSchema = []column{
+	{"actors", "id", "integer", "NO"},
+	{"actors", "name", "character varying(50)", "NO"},
+	{"actors", "surname", "character varying(50)", "NO"},
+	{"actors", "fathername", "character varying(50)", "YES"},
+	{"actors", "birthdate", "character(8)", "NO"},
+	{"actors", "sex", "character(1)", "NO"},
+	{"actorsfilms", "actor_id", "integer", "NO"},
+	{"actorsfilms", "film_id", "integer", "NO"},
+	{"films", "id", "integer", "NO"},
+	{"films", "name", "character varying(50)", "NO"},
+	{"films", "description", "character varying(1000)", "NO"},
+	{"films", "date", "character(8)", "NO"},
+	{"films", "rating", "integer", "NO"},
+	{"users", "login", "character varying(50)", "NO"},
+	{"users", "password", "character varying(200)", "NO"},
+	{"users", "role", "integer", "NO"},
+}
+
+// column is a column of a table as information_schema describes it.
+type column struct {
+	Table    string
+	Name     string
+	Type     string
+	Nullable string
+}
+
+// String returns the column as table.column type, with null if it is nullable.
+func (c column) String() string {
+	s := c.Table + "." + c.Name + " " + c.Type
+	if c.Nullable == "YES" {
+		s += " null"
+	}
+	return s
+}
+
+// diffColumns describes how the columns found differ from the wanted ones, empty if they do not.
+func diffColumns(found, want []column) []string {
+	wanted := map[column]bool{}
+	for _, c := range want {
+		wanted[c] = true
+	}
+	var diff []string
+	for _, c := range found {
+		if !wanted[c] {
+			diff = append(diff, "unexpected "+c.String())
+		}
+		delete(wanted, c)
+	}
+	for _, c := range want {
+		if wanted[c] {
+			diff = append(diff, "missing "+c.String())
+		}
+	}
+	return diff
+}
+
+// adopt records the initial migration as applied if the database was created by the former init.sql.
+// A database with the tables of the film library in any other shape is refused, none of the later
+// migrations can tell whether its changes are already there.
+func (m *Migrator) adopt(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	var exists bool
+	if err := conn.QueryRowContext(ctx, "SELECT to_regclass('films') IS NOT NULL").Scan(&exists); err != nil || !exists {
+		return map[int]time.Time{}, err
+	}
+	rows, err := conn.QueryContext(ctx, `SELECT table_name, column_name,
+        data_type || coalesce('(' || character_maximum_length || ')', ''), is_nullable
+FROM information_schema.columns
+WHERE table_schema = current_schema() AND table_name IN ('films', 'actors', 'actorsfilms', 'users')
+ORDER BY table_name, ordinal_position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var found []column
+	for rows.Next() {
+		var c column
+		if err := rows.Scan(&c.Table, &c.Name, &c.Type, &c.Nullable); err != nil {
+			return nil, err
+		}
+		found = append(found, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if diff := diffColumns(found, initialSchema); len(diff) > 0 {
+		return nil, fmt.Errorf("the database has no schema_migrations but its tables are not the ones of migration %s (%s), "+
+			"bring it to a known version by hand and record that in schema_migrations", m.migrations[0], strings.Join(diff, ", "))
+	}
+	err = inTx(ctx, conn, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, "name") VALUES ($1, $2)`,
+			m.migrations[0].Version, m.migrations[0].Name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return appliedVersions(ctx, conn)
+}
+
+// apply runs the up or down script of the migration and records it in the same transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	err := inTx(ctx, conn, func(tx *sql.Tx) error {
+		script, record := migration.Up, `INSERT INTO schema_migrations (version, "name") VALUES ($1, $2)`
+		if !up {
+			script, record = migration.Down, "DELETE FROM schema_migrations WHERE version = $1 AND \"name\" = $2"
+		}
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, record, migration.Version, migration.Name)
+		return err
+	})
+	if err != nil {
+		direction := "up"
+		if !up {
+			direction = "down"
+		}
+		return fmt.Errorf("migration %s %s: %w", migration, direction, err)
+	}
+	return nil
+}
+
+// rollback rolls back the latest applied migration.
+func (m *Migrator) rollback(ctx context.Context, conn *sql.Conn, applied map[int]time.Time) (Migration, error) {
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; ok {
+			return migration, m.apply(ctx, conn, migration, false)
+		}
+	}
+	return Migration{}, ErrNoChange
+}
+
+// querier is a database or a connection.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// appliedVersions returns the applied versions along with the times they were applied,
+// none if the schema_migrations table does not exist yet.
+func appliedVersions(ctx context.Context, q querier) (map[int]time.Time, error) {
+	applied := map[int]time.Time{}
+	var exists bool
+	if err := q.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil || !exists {
+		return applied, err
+	}
+	rows, err := q.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// inTx runs fn in a transaction of the connection, committing it if fn succeeds.
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,95 @@
+package migrate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Fatalf("migration %s follows version %d", m, i)
+		}
+	}
+	// the first migration is the schema of the former init.sql, the later ones change it
+	if first := migrations[0]; first.Name != "initial_schema" || !strings.Contains(first.Up, `"role" int DEFAULT 0 NOT NULL`) {
+		t.Fatalf("migration 1 is %s, want the initial schema", first)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	file := &fstest.MapFile{Data: []byte("SELECT 1;")}
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		error string
+	}{
+		{"ordered by version", fstest.MapFS{
+			"migrations/0002_b.up.sql": file, "migrations/0002_b.down.sql": file,
+			"migrations/0001_a.up.sql": file, "migrations/0001_a.down.sql": file,
+		}, ""},
+		{"missing down", fstest.MapFS{"migrations/0001_a.up.sql": file}, "needs both"},
+		{"two names", fstest.MapFS{"migrations/0001_a.up.sql": file, "migrations/0001_b.down.sql": file}, "named both"},
+		{"bad name", fstest.MapFS{"migrations/first.sql": file}, "is not named"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := load(tt.files)
+			if tt.error != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.error) {
+					t.Fatalf("error is %v, want it to contain %q", err, tt.error)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(migrations) != 2 || migrations[0].String() != "0001_a" || migrations[1].String() != "0002_b" {
+				t.Fatalf("migrations are %v", migrations)
+			}
+		})
+	}
+}
+
+func TestDiffColumns(t *testing.T) {
+	without := func(name string) []column {
+		var columns []column
+		for _, c := range initialSchema {
+			if c.Table+"."+c.Name != name {
+				columns = append(columns, c)
+			}
+		}
+		return columns
+	}
+	tests := []struct {
+		name  string
+		found []column
+		want  []string
+	}{
+		{"initial schema", initialSchema, nil},
+		{"added column", append(without(""), column{"films", "votes", "integer", "NO"}),
+			[]string{"unexpected films.votes integer"}},
+		{"changed type", append(without("films.rating"), column{"films", "rating", "real", "NO"}),
+			[]string{"unexpected films.rating real", "missing films.rating integer"}},
+		{"changed nullability", append(without("actors.fathername"), column{"actors", "fathername", "character varying(50)", "NO"}),
+			[]string{"unexpected actors.fathername character varying(50)", "missing actors.fathername character varying(50) null"}},
+		{"missing table", without("actorsfilms.actor_id")[:6],
+			[]string{"missing actorsfilms.actor_id integer", "missing actorsfilms.film_id integer", "missing films.id integer",
+				"missing films.name character varying(50)", "missing films.description character varying(1000)",
+				"missing films.date character(8)", "missing films.rating integer", "missing users.login character varying(50)",
+				"missing users.password character varying(200)", "missing users.role integer"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffColumns(tt.found, initialSchema); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("diffColumns = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+DROP TABLE users;
+DROP TABLE actorsfilms;
+DROP TABLE actors;
+DROP TABLE films;
@@ -0,0 +1,33 @@
+CREATE TABLE films (
+        id int GENERATED ALWAYS AS IDENTITY NOT NULL,
+        "name" varchar(50) NOT NULL,
+        "description" varchar(1000) NOT NULL,
+        "date" char(8) NOT NULL,
+        rating int4 DEFAULT 0 NOT NULL,
+        CONSTRAINT films_pk PRIMARY KEY (id)
+);
+
+CREATE TABLE actors (
+        id int GENERATED ALWAYS AS IDENTITY NOT NULL,
+        "name" varchar(50) NOT NULL,
+        surname varchar(50) NOT NULL,
+        fathername varchar(50) NULL,
+        birthdate char(8) NOT NULL,
+        sex char(1) NOT NULL,
+        CONSTRAINT actor_pk PRIMARY KEY (id)
+);
+
+CREATE TABLE actorsfilms (
+        actor_id int4 not NULL,
+        film_id int4 not NULL,
+        CONSTRAINT actorsfilms_pk PRIMARY KEY (actor_id, film_id),
+        CONSTRAINT actors_films_actor_fk FOREIGN KEY (actor_id) REFERENCES actors(id) ON DELETE CASCADE ON UPDATE CASCADE,
+        CONSTRAINT actors_films_film_fk FOREIGN KEY (film_id) REFERENCES films(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE users (
+        "login" varchar(50) NOT NULL,
+        "password" varchar(200) NOT NULL,
+        "role" int DEFAULT 0 NOT NULL,
+        CONSTRAINT users_pk PRIMARY KEY ("login")
+);
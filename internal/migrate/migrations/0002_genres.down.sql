@@ -0,0 +1,2 @@
+DROP TABLE filmsgenres;
+DROP TABLE genres;
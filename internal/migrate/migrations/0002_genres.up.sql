@@ -0,0 +1,14 @@
+CREATE TABLE genres (
+        id int GENERATED ALWAYS AS IDENTITY NOT NULL,
+        "name" varchar(50) NOT NULL,
+        CONSTRAINT genres_pk PRIMARY KEY (id),
+        CONSTRAINT genres_name_un UNIQUE ("name")
+);
+
+CREATE TABLE filmsgenres (
+        film_id int4 not NULL,
+        genre_id int4 not NULL,
+        CONSTRAINT filmsgenres_pk PRIMARY KEY (film_id, genre_id),
+        CONSTRAINT films_genres_film_fk FOREIGN KEY (film_id) REFERENCES films(id) ON DELETE CASCADE ON UPDATE CASCADE,
+        CONSTRAINT films_genres_genre_fk FOREIGN KEY (genre_id) REFERENCES genres(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
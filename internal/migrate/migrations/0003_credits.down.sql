@@ -0,0 +1,10 @@
+-- Only one credit per actor and film fits the old key, the crew credits are dropped.
+DELETE FROM actorsfilms WHERE credit_type <> 'actor';
+
+ALTER TABLE actorsfilms DROP CONSTRAINT actorsfilms_pk;
+
+ALTER TABLE actorsfilms
+        ADD CONSTRAINT actorsfilms_pk PRIMARY KEY (actor_id, film_id),
+        DROP COLUMN billing,
+        DROP COLUMN "character",
+        DROP COLUMN credit_type;
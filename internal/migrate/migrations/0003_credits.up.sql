@@ -0,0 +1,10 @@
+-- The existing rows are acting credits.
+ALTER TABLE actorsfilms
+        ADD COLUMN credit_type varchar(20) DEFAULT 'actor' NOT NULL,
+        ADD COLUMN "character" varchar(100) NULL,
+        ADD COLUMN billing int4 NULL,
+        ADD CONSTRAINT actorsfilms_credit_type_check CHECK (credit_type IN ('actor', 'director', 'writer', 'producer', 'composer', 'cinematographer')),
+        ADD CONSTRAINT actorsfilms_billing_check CHECK (billing > 0),
+        DROP CONSTRAINT actorsfilms_pk;
+
+ALTER TABLE actorsfilms ADD CONSTRAINT actorsfilms_pk PRIMARY KEY (actor_id, film_id, credit_type);
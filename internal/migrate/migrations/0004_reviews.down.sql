@@ -0,0 +1,6 @@
+-- The films without reviews get their ratings back as they were, the reviewed ones keep the average rounded.
+DROP TABLE reviews;
+
+ALTER TABLE films
+        DROP COLUMN votes,
+        ALTER COLUMN rating TYPE int4 USING round(rating);
@@ -0,0 +1,20 @@
+-- The rating becomes the average score of the reviews. The ratings set by hand stay
+-- until the first review of their film replaces them.
+ALTER TABLE films
+        ALTER COLUMN rating TYPE real,
+        ADD COLUMN votes int4 DEFAULT 0 NOT NULL;
+
+CREATE TABLE reviews (
+        id int GENERATED ALWAYS AS IDENTITY NOT NULL,
+        film_id int4 NOT NULL,
+        "login" varchar(50) NOT NULL,
+        score int2 NOT NULL,
+        "text" varchar(2000) NULL,
+        created_at timestamptz DEFAULT now() NOT NULL,
+        updated_at timestamptz DEFAULT now() NOT NULL,
+        CONSTRAINT reviews_pk PRIMARY KEY (id),
+        CONSTRAINT reviews_film_login_un UNIQUE (film_id, "login"),
+        CONSTRAINT reviews_score_check CHECK (score BETWEEN 1 AND 10),
+        CONSTRAINT reviews_film_fk FOREIGN KEY (film_id) REFERENCES films(id) ON DELETE CASCADE ON UPDATE CASCADE,
+        CONSTRAINT reviews_user_fk FOREIGN KEY ("login") REFERENCES users("login") ON DELETE CASCADE ON UPDATE CASCADE
+);
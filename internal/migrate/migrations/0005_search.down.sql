@@ -0,0 +1,6 @@
+-- The indexes go along with their columns, pg_trgm stays, other schemas may use it.
+ALTER TABLE actors
+        DROP COLUMN "search",
+        DROP COLUMN full_name;
+
+ALTER TABLE films DROP COLUMN "search";
@@ -0,0 +1,22 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE films
+        ADD COLUMN "search" tsvector GENERATED ALWAYS AS (
+                setweight(to_tsvector('russian', "name"), 'A') ||
+                setweight(to_tsvector('english', "name"), 'A') ||
+                setweight(to_tsvector('russian', "description"), 'B') ||
+                setweight(to_tsvector('english', "description"), 'B')
+        ) STORED;
+
+CREATE INDEX films_search_idx ON films USING gin ("search");
+CREATE INDEX films_name_trgm_idx ON films USING gin ("name" gin_trgm_ops);
+
+ALTER TABLE actors
+        ADD COLUMN full_name varchar(152) GENERATED ALWAYS AS ("name" || ' ' || surname || coalesce(' ' || fathername, '')) STORED,
+        ADD COLUMN "search" tsvector GENERATED ALWAYS AS (
+                to_tsvector('russian', "name" || ' ' || surname || coalesce(' ' || fathername, '')) ||
+                to_tsvector('english', "name" || ' ' || surname || coalesce(' ' || fathername, ''))
+        ) STORED;
+
+CREATE INDEX actors_search_idx ON actors USING gin ("search");
+CREATE INDEX actors_full_name_trgm_idx ON actors USING gin (full_name gin_trgm_ops);
@@ -0,0 +1 @@
+DROP INDEX actors_surname_name_idx;
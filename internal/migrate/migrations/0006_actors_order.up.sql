@@ -0,0 +1 @@
+CREATE INDEX actors_surname_name_idx ON actors (surname, "name", id);
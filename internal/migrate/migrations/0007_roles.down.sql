@@ -0,0 +1,11 @@
+-- Editors and moderators lose their rights, only administrators keep role 1.
+ALTER TABLE users
+        DROP CONSTRAINT users_role_fk,
+        ALTER COLUMN "role" DROP DEFAULT;
+
+ALTER TABLE users
+        ALTER COLUMN "role" TYPE int USING CASE "role" WHEN 'admin' THEN 1 ELSE 0 END,
+        ALTER COLUMN "role" SET DEFAULT 0;
+
+DROP TABLE rolepermissions;
+DROP TABLE roles;
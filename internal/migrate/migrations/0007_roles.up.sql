@@ -0,0 +1,41 @@
+CREATE TABLE roles (
+        "name" varchar(20) NOT NULL,
+        CONSTRAINT roles_pk PRIMARY KEY ("name")
+);
+
+CREATE TABLE rolepermissions (
+        "role" varchar(20) NOT NULL,
+        "permission" varchar(50) NOT NULL,
+        CONSTRAINT rolepermissions_pk PRIMARY KEY ("role", "permission"),
+        CONSTRAINT rolepermissions_role_fk FOREIGN KEY ("role") REFERENCES roles("name") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- The roles are part of the schema rather than of the seed: new users get the viewer role.
+INSERT INTO roles ("name") VALUES
+        ('viewer'),
+        ('editor'),
+        ('moderator'),
+        ('admin');
+
+INSERT INTO rolepermissions ("role","permission") VALUES
+        ('viewer','films:read'),
+        ('viewer','reviews:write'),
+        ('editor','films:read'),
+        ('editor','reviews:write'),
+        ('editor','catalog:write'),
+        ('moderator','films:read'),
+        ('moderator','reviews:write'),
+        ('moderator','reviews:moderate'),
+        ('admin','films:read'),
+        ('admin','reviews:write'),
+        ('admin','catalog:write'),
+        ('admin','reviews:moderate'),
+        ('admin','users:manage');
+
+-- Role 1 was the administrator, every other user could only read.
+ALTER TABLE users ALTER COLUMN "role" DROP DEFAULT;
+
+ALTER TABLE users
+        ALTER COLUMN "role" TYPE varchar(20) USING CASE "role" WHEN 1 THEN 'admin' ELSE 'viewer' END,
+        ALTER COLUMN "role" SET DEFAULT 'viewer',
+        ADD CONSTRAINT users_role_fk FOREIGN KEY ("role") REFERENCES roles("name") ON UPDATE CASCADE;
@@ -0,0 +1,2 @@
+DROP TABLE refreshtokens;
+DROP TABLE sessions;
@@ -0,0 +1,19 @@
+CREATE TABLE sessions (
+        id varchar(32) NOT NULL,
+        "login" varchar(50) NOT NULL,
+        created_at timestamptz DEFAULT now() NOT NULL,
+        revoked_at timestamptz NULL,
+        CONSTRAINT sessions_pk PRIMARY KEY (id),
+        CONSTRAINT sessions_user_fk FOREIGN KEY ("login") REFERENCES users("login") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE refreshtokens (
+        token_hash char(64) NOT NULL,
+        session_id varchar(32) NOT NULL,
+        expires_at timestamptz NOT NULL,
+        used_at timestamptz NULL,
+        CONSTRAINT refreshtokens_pk PRIMARY KEY (token_hash),
+        CONSTRAINT refreshtokens_session_fk FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX refreshtokens_session_idx ON refreshtokens (session_id);
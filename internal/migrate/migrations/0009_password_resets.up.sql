@@ -0,0 +1,8 @@
+CREATE TABLE passwordresets (
+        token_hash char(64) NOT NULL,
+        "login" varchar(50) NOT NULL,
+        expires_at timestamptz NOT NULL,
+        used_at timestamptz NULL,
+        CONSTRAINT passwordresets_pk PRIMARY KEY (token_hash),
+        CONSTRAINT passwordresets_user_fk FOREIGN KEY ("login") REFERENCES users("login") ON DELETE CASCADE ON UPDATE CASCADE
+);
@@ -0,0 +1,6 @@
+ALTER TABLE users
+        DROP COLUMN created_at,
+        DROP COLUMN disabled,
+        DROP COLUMN "language",
+        DROP COLUMN email,
+        DROP COLUMN display_name;
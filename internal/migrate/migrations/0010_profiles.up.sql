@@ -0,0 +1,7 @@
+ALTER TABLE users
+        ADD COLUMN display_name varchar(100) NULL,
+        ADD COLUMN email varchar(254) NULL,
+        ADD COLUMN "language" varchar(10) DEFAULT 'ru' NOT NULL,
+        ADD COLUMN disabled boolean DEFAULT false NOT NULL,
+        ADD COLUMN created_at timestamptz DEFAULT now() NOT NULL,
+        ADD CONSTRAINT users_email_un UNIQUE (email);
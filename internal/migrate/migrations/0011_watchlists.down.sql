@@ -0,0 +1,2 @@
+DROP TABLE watched;
+DROP TABLE watchlist;
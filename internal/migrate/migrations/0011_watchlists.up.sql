@@ -0,0 +1,19 @@
+CREATE TABLE watchlist (
+        "login" varchar(50) NOT NULL,
+        film_id int4 NOT NULL,
+        added_at timestamptz DEFAULT now() NOT NULL,
+        CONSTRAINT watchlist_pk PRIMARY KEY ("login", film_id),
+        CONSTRAINT watchlist_user_fk FOREIGN KEY ("login") REFERENCES users("login") ON DELETE CASCADE ON UPDATE CASCADE,
+        CONSTRAINT watchlist_film_fk FOREIGN KEY (film_id) REFERENCES films(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE watched (
+        "login" varchar(50) NOT NULL,
+        film_id int4 NOT NULL,
+        watched_on char(8) NOT NULL,
+        CONSTRAINT watched_pk PRIMARY KEY ("login", film_id, watched_on),
+        CONSTRAINT watched_user_fk FOREIGN KEY ("login") REFERENCES users("login") ON DELETE CASCADE ON UPDATE CASCADE,
+        CONSTRAINT watched_film_fk FOREIGN KEY (film_id) REFERENCES films(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX watched_login_date_idx ON watched ("login", watched_on DESC);
@@ -0,0 +1,2 @@
+DROP TABLE collectionfilms;
+DROP TABLE collections;
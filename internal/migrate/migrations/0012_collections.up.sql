@@ -0,0 +1,25 @@
+CREATE TABLE collections (
+        id int GENERATED ALWAYS AS IDENTITY NOT NULL,
+        slug varchar(80) NOT NULL,
+        "login" varchar(50) NOT NULL,
+        "name" varchar(100) NOT NULL,
+        "description" varchar(1000) NULL,
+        public boolean DEFAULT false NOT NULL,
+        created_at timestamptz DEFAULT now() NOT NULL,
+        updated_at timestamptz DEFAULT now() NOT NULL,
+        CONSTRAINT collections_pk PRIMARY KEY (id),
+        CONSTRAINT collections_slug_un UNIQUE (slug),
+        CONSTRAINT collections_user_fk FOREIGN KEY ("login") REFERENCES users("login") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX collections_login_idx ON collections ("login");
+CREATE INDEX collections_public_idx ON collections (updated_at DESC, id DESC) WHERE public;
+
+CREATE TABLE collectionfilms (
+        collection_id int4 NOT NULL,
+        film_id int4 NOT NULL,
+        "position" int4 NOT NULL,
+        CONSTRAINT collectionfilms_pk PRIMARY KEY (collection_id, film_id),
+        CONSTRAINT collectionfilms_collection_fk FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE ON UPDATE CASCADE,
+        CONSTRAINT collectionfilms_film_fk FOREIGN KEY (film_id) REFERENCES films(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
@@ -0,0 +1,65 @@
+-- Sample catalog and users. Rows are matched by their natural keys, so seeding again changes nothing.
+
+INSERT INTO actors (name,surname,fathername,birthdate,sex)
+SELECT v.* FROM (VALUES
+        ('Роберт','Дауни-младший',NULL,'19650404', 'm'),
+        ('Киану','Ривз',NULL,'19640902', 'm'),
+        ('Бурунов','Сергей','Александрович','19770306', 'm')
+) v(name,surname,fathername,birthdate,sex)
+WHERE NOT EXISTS (SELECT 1 FROM actors a WHERE a.name = v.name AND a.surname = v.surname AND a.birthdate = v.birthdate);
+
+INSERT INTO films ("name", "description", "date")
+SELECT v.* FROM (VALUES
+        ('Мстители: Финал','Оставшиеся в живых члены команды Мстителей и их союзники должны разработать новый план, который поможет противостоять разрушительным действиям могущественного титана Таноса. После наиболее масштабной и трагической битвы в истории они не могут допустить ошибку.','20190429'),
+        ('Джон Уик 3','Киллер-изгой бежит от байкеров-самураев и других неприятностей. Мощное продолжение остросюжетной франшизы','20190516'),
+        ('Затмение','Самозванец участвует в шоу экстрасенсов. Очаровательный Александр Петров и вихрь мистических происшествий','20161125')
+) v("name","description","date")
+WHERE NOT EXISTS (SELECT 1 FROM films f WHERE f."name" = v."name" AND f."date" = v."date");
+
+INSERT INTO actorsfilms (actor_id,film_id,credit_type,"character",billing)
+SELECT a.id, f.id, v.credit_type, v."character", v.billing FROM (VALUES
+        ('Роберт','Дауни-младший','Мстители: Финал','actor','Тони Старк',1),
+        ('Киану','Ривз','Джон Уик 3','actor','Джон Уик',1),
+        ('Бурунов','Сергей','Затмение','actor','Гриша',2)
+) v(actor_name,actor_surname,film,credit_type,"character",billing)
+JOIN actors a ON a.name = v.actor_name AND a.surname = v.actor_surname
+JOIN films f ON f."name" = v.film
+ON CONFLICT DO NOTHING;
+
+INSERT INTO genres ("name") VALUES
+        ('боевик'),
+        ('фантастика'),
+        ('комедия'),
+        ('мистика')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO filmsgenres (film_id,genre_id)
+SELECT f.id, g.id FROM (VALUES
+        ('Мстители: Финал','боевик'),
+        ('Мстители: Финал','фантастика'),
+        ('Джон Уик 3','боевик'),
+        ('Затмение','комедия'),
+        ('Затмение','мистика')
+) v(film,genre)
+JOIN films f ON f."name" = v.film
+JOIN genres g ON g."name" = v.genre
+ON CONFLICT DO NOTHING;
+
+INSERT INTO users ("login","password","role") VALUES
+        ('alice_smith','$2a$12$EEWfSU1DD4NYqF9V0sOX7.jxky5YGC.4yTi2CSjsAkGhW9ohDKNdm','viewer'),
+        ('john_doe','$2a$12$RFOEwd0Z8Fw.ZYeTwzpFpeSjPka2nlhoZSjebYqR4V.ZVENwFtCo.','admin')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO reviews (film_id,"login",score,"text")
+SELECT f.id, v."login", v.score, v."text" FROM (VALUES
+        ('Мстители: Финал','alice_smith',8,'Достойное завершение саги'),
+        ('Мстители: Финал','john_doe',8,NULL),
+        ('Джон Уик 3','alice_smith',7,NULL),
+        ('Затмение','john_doe',6,'Смешно, но местами затянуто')
+) v(film,"login",score,"text")
+JOIN films f ON f."name" = v.film
+ON CONFLICT DO NOTHING;
+
+UPDATE films f SET
+        rating = coalesce((SELECT avg(r.score) FROM reviews r WHERE r.film_id = f.id), 0),
+        votes = (SELECT count(*) FROM reviews r WHERE r.film_id = f.id);
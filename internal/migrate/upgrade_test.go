@@ -0,0 +1,125 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+// initSQLDB returns a database holding the schema of the former init.sql without schema_migrations.
+// It lives in a schema of its own dropped at the end, the test is skipped without TEST_DATABASE_URL.
+func initSQLDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+	schema := fmt.Sprintf("migrate_test_%d", os.Getpid())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	// pg_trgm may already be installed in public, so it stays on the path
+	searchPath := schema + ",public"
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		query := u.Query()
+		query.Set("search_path", searchPath)
+		u.RawQuery = query.Encode()
+		dsn = u.String()
+	} else {
+		dsn += " search_path=" + searchPath
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(migrations[0].Up); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestUpgradeKeepsTheData(t *testing.T) {
+	db := initSQLDB(t)
+	ctx := context.Background()
+	_, err := db.Exec(`INSERT INTO films ("name", "description", "date", rating) VALUES ('Затмение', 'Описание', '20161125', 6);
+		INSERT INTO users ("login", "password", "role") VALUES ('alice_smith', 'hash', 0), ('john_doe', 'hash', 1)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var rating float32
+	var votes int
+	if err := db.QueryRow("SELECT rating, votes FROM films").Scan(&rating, &votes); err != nil {
+		t.Fatal(err)
+	}
+	if rating != 6 || votes != 0 {
+		t.Fatalf("the film has rating %v of %d votes after the upgrade, want the rating set by hand", rating, votes)
+	}
+	var roles string
+	if err := db.QueryRow(`SELECT string_agg("role", ',' ORDER BY "login") FROM users`).Scan(&roles); err != nil {
+		t.Fatal(err)
+	}
+	if roles != "viewer,admin" {
+		t.Fatalf("roles after the upgrade are %s, want viewer,admin", roles)
+	}
+
+	// every down migration runs back to the initial schema
+	for {
+		if _, err := migrator.Down(ctx); errors.Is(err, ErrNoChange) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var intRating, role int
+	if err := db.QueryRow("SELECT rating FROM films").Scan(&intRating); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow(`SELECT "role" FROM users WHERE "login" = 'john_doe'`).Scan(&role); err != nil {
+		t.Fatal(err)
+	}
+	if intRating != 6 || role != 1 {
+		t.Fatalf("after rolling back the rating is %d and the admin role %d, want 6 and 1", intRating, role)
+	}
+}
+
+func TestAdoptRefusesAnotherSchema(t *testing.T) {
+	db := initSQLDB(t)
+	if _, err := db.Exec("ALTER TABLE films ALTER COLUMN rating TYPE real"); err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrator.Up(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "films.rating real") {
+		t.Fatalf("Up of a database in another shape returned %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+// Package server runs the HTTP server of the film library and shuts it down gracefully.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"VK_app/internal/config"
+)
+
+// readHeaderTimeout bounds the headers of a request, slow clients must not hold connections.
+const readHeaderTimeout = 5 * time.Second
+
+// New returns the server of the handler with the configured address and connection timeouts.
+func New(cfg config.ServerConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       time.Duration(cfg.ReadTimeout),
+		WriteTimeout:      time.Duration(cfg.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.IdleTimeout),
+	}
+}
+
+// Run listens on the configured address and serves until the context is done, see Serve.
+func Run(ctx context.Context, srv *http.Server, cfg config.ServerConfig) error {
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+	return Serve(ctx, srv, listener, cfg)
+}
+
+// Serve serves the connections of the listener, over TLS if it is configured, until the context is done.
+// It then stops accepting connections and waits at most cfg.ShutdownTimeout for the requests in flight
+// to complete, closing the connections still open after that.
+//
+// It returns nil after a complete shutdown, otherwise the error that stopped the server.
+func Serve(ctx context.Context, srv *http.Server, listener net.Listener, cfg config.ServerConfig) error {
+	errs := make(chan error, 1)
+	go func() {
+		if cfg.TLS() {
+			errs <- srv.ServeTLS(listener, cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			errs <- srv.Serve(listener)
+		}
+	}()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	log.Println("shutting down, waiting for the requests in flight")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout))
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("requests in flight did not complete in %s: %w", time.Duration(cfg.ShutdownTimeout), err)
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
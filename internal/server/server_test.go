@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"VK_app/internal/config"
+)
+
+// slowHandler answers "done" after delay, signalling started when a request arrives.
+func slowHandler(started chan<- struct{}, delay time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		time.Sleep(delay)
+		io.WriteString(w, "done")
+	})
+}
+
+// result is the outcome of a request.
+type result struct {
+	body string
+	err  error
+}
+
+// serve starts serving the handler on a local port and returns its address,
+// the function cancelling the serve context and the channel Serve returns to.
+func serve(t *testing.T, handler http.Handler, shutdownTimeout time.Duration) (string, context.CancelFunc, <-chan error) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.ServerConfig{
+		ReadTimeout:     config.Duration(5 * time.Second),
+		WriteTimeout:    config.Duration(5 * time.Second),
+		IdleTimeout:     config.Duration(5 * time.Second),
+		ShutdownTimeout: config.Duration(shutdownTimeout),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, New(cfg, handler), listener, cfg) }()
+	return "http://" + listener.Addr().String(), cancel, done
+}
+
+// get requests the URL in the background.
+func get(url string) <-chan result {
+	results := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		results <- result{body: string(body), err: err}
+	}()
+	return results
+}
+
+func TestServeCompletesRequestsInFlight(t *testing.T) {
+	started := make(chan struct{}, 1)
+	url, cancel, done := serve(t, slowHandler(started, 300*time.Millisecond), 5*time.Second)
+
+	results := get(url)
+	<-started
+	cancel()
+
+	select {
+	case r := <-results:
+		if r.err != nil || r.body != "done" {
+			t.Fatalf("request in flight got %q, %v", r.body, r.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("request in flight got no response")
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Serve returned %v after a complete shutdown", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return within the grace period")
+	}
+	if _, err := http.Get(url); err == nil {
+		t.Fatal("new connections are still accepted after the shutdown")
+	}
+}
+
+func TestServeGivesUpAfterTheGracePeriod(t *testing.T) {
+	started := make(chan struct{}, 1)
+	url, cancel, done := serve(t, slowHandler(started, 3*time.Second), 200*time.Millisecond)
+
+	results := get(url)
+	<-started
+	cancelled := time.Now()
+	cancel()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("Serve returned nil though a request was cut off")
+		}
+		if waited := time.Since(cancelled); waited > 2*time.Second {
+			t.Fatalf("Serve returned %s after the cancellation, the grace period is 200ms", waited)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Serve did not return after the grace period")
+	}
+	select {
+	case r := <-results:
+		if r.err == nil {
+			t.Fatalf("request cut off by the shutdown got %q", r.body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection of the request cut off was not closed")
+	}
+}
@@ -1,19 +1,143 @@
 package structures
 
+import "time"
+
 //swagger:model
 type User struct {
 	Login    string `json:"login" example:"john_doe"`
 	Password string `json:"password" example:"psjfb10"`
-	Role     int    `json:"role" example:"1"`
+	Role     string `json:"role,omitempty" example:"viewer" swaggerignore:"true"`
+	Disabled bool   `json:"-"`
+}
+
+//swagger:model
+type Profile struct {
+	Login       string    `json:"login" example:"john_doe"`
+	DisplayName string    `json:"display_name" example:"John Doe"`
+	Email       string    `json:"email" example:"john@example.com"`
+	Language    string    `json:"language" example:"en"`
+	Role        string    `json:"role" example:"viewer"`
+	Disabled    bool      `json:"disabled" example:"false"`
+	CreatedAt   time.Time `json:"created_at" example:"2024-03-16T12:00:00Z"`
+}
+
+// ProfileUpdate holds the profile fields to change, nil fields are kept.
+//
+//swagger:model
+type ProfileUpdate struct {
+	DisplayName *string `json:"display_name,omitempty" example:"John Doe"`
+	Email       *string `json:"email,omitempty" example:"john@example.com"`
+	Language    *string `json:"language,omitempty" example:"en"`
+}
+
+//swagger:model
+type UserListQuery struct {
+	Query    string `form:"query" json:"query" example:"john"`
+	Disabled *bool  `form:"disabled" json:"disabled" example:"false"`
+	Limit    int    `form:"limit" json:"limit" example:"20"`
+	Offset   int    `form:"offset" json:"offset" example:"0"`
+}
+
+//swagger:model
+type UserList struct {
+	Users []Profile `json:"users"`
+	Total int       `json:"total" example:"1"`
+}
+
+//swagger:model
+type AccountDeletion struct {
+	Password string `json:"password" binding:"required" example:"Film-lover-2024"`
+}
+
+// Session is a login of a user, it lives as long as its refresh tokens keep being rotated.
+type Session struct {
+	Id        string
+	Login     string
+	CreatedAt time.Time
+}
+
+// RefreshToken is a single-use token of a session, only its hash is stored.
+type RefreshToken struct {
+	Hash      string
+	SessionID string
+	ExpiresAt time.Time
+}
+
+//swagger:model
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" example:"q4cV1n5Xk2f8xY0bJmS3aR7tW9uE6hLzPdGoNiKcBvA"`
+}
+
+// TokenResponse is the result of a login or a refresh. In cookie mode the tokens are only
+// set as HttpOnly cookies and the body carries the CSRF token instead.
+//
+//swagger:model
+type TokenResponse struct {
+	Message      string `json:"message" example:"login was completed successfully"`
+	AccessToken  string `json:"access_token,omitempty" example:"eyJhbGciOiJIUzI1NiIsImtpZCI6ImRlZmF1bHQiLCJ0eXAiOiJKV1QifQ..."`
+	TokenType    string `json:"token_type,omitempty" example:"Bearer"`
+	ExpiresIn    int    `json:"expires_in" example:"900"`
+	RefreshToken string `json:"refresh_token,omitempty" example:"q4cV1n5Xk2f8xY0bJmS3aR7tW9uE6hLzPdGoNiKcBvA"`
+	CSRFToken    string `json:"csrf_token,omitempty" example:"0f3c9a7d5e1b2c4a6f8e0d2c4b6a8f0e"`
+}
+
+// JWK is a public key in the JSON Web Key format.
+//
+//swagger:model
+type JWK struct {
+	KeyType   string `json:"kty" example:"OKP"`
+	KeyID     string `json:"kid" example:"2024-06"`
+	Use       string `json:"use" example:"sig"`
+	Algorithm string `json:"alg" example:"EdDSA"`
+	Curve     string `json:"crv,omitempty" example:"Ed25519"`
+	X         string `json:"x,omitempty" example:"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+//swagger:model
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+//swagger:model
+type Role struct {
+	Name        string   `json:"name" example:"editor"`
+	Permissions []string `json:"permissions" example:"films:read,reviews:write,catalog:write"`
+}
+
+//swagger:model
+type UserLogin struct {
+	Login string `json:"login" example:"alice_smith"`
+}
+
+//swagger:model
+type PasswordChange struct {
+	OldPassword string `json:"old_password" binding:"required" example:"psjfb10"`
+	NewPassword string `json:"new_password" binding:"required" example:"Film-lover-2024"`
+}
+
+//swagger:model
+type PasswordReset struct {
+	Token       string `json:"token" binding:"required" example:"q4cV1n5Xk2f8xY0bJmS3aR7tW9uE6hLzPdGoNiKcBvA"`
+	NewPassword string `json:"new_password" binding:"required" example:"Film-lover-2024"`
+}
+
+//swagger:model
+type UserRole struct {
+	Login string `json:"login" example:"alice_smith"`
+	Role  string `json:"role" example:"moderator"`
 }
 
 //swagger:model
 type Film struct {
-	Id          int     `json:"id" example:"3"`
-	Name        string  `json:"name" example:"Затмение"`
-	Description string  `json:"description" example:"Описание фильма"`
-	Date        string  `json:"date" example:"20161125"`
-	Rating      float32 `json:"rating" example:"5.8"`
+	Id          int      `json:"id" example:"3"`
+	Name        string   `json:"name" example:"Затмение"`
+	Description string   `json:"description" example:"Описание фильма"`
+	Date        string   `json:"date" example:"20161125"`
+	Rating      float32  `json:"rating" example:"5.8"`
+	Votes       int      `json:"votes" example:"12"`
+	Genres      []string `json:"genres,omitempty" example:"комедия,мистика"`
 }
 
 //swagger:model
@@ -26,16 +150,52 @@ type Actor struct {
 	Sex        string `json:"sex" example:"m"`
 }
 
+// ActorFilm is a credit of a person in a film. CreditType defaults to "actor",
+// Character and Billing are optional.
+//
 //swagger:model
 type ActorFilm struct {
-	ActorID int `json:"actor_id" example:"9"`
+	ActorID    int    `json:"actor_id" example:"9"`
+	FilmID     int    `json:"film_id" example:"3"`
+	CreditType string `json:"credit_type" example:"actor"`
+	Character  string `json:"character,omitempty" example:"Гриша"`
+	Billing    int    `json:"billing,omitempty" example:"1"`
+}
+
+//swagger:model
+type CreditResponse struct {
+	ActorID    int    `json:"actor_id" example:"9"`
+	Name       string `json:"name" example:"Сергей"`
+	Surname    string `json:"surname" example:"Бурунов"`
+	CreditType string `json:"credit_type" example:"actor"`
+	Character  string `json:"character,omitempty" example:"Гриша"`
+	Billing    int    `json:"billing,omitempty" example:"1"`
+}
+
+//swagger:model
+type FilmDetails struct {
+	Film
+	Cast []CreditResponse `json:"cast"`
+}
+
+//swagger:model
+type Genre struct {
+	Id   int    `json:"id" example:"2"`
+	Name string `json:"name" example:"комедия"`
+}
+
+//swagger:model
+type FilmGenre struct {
 	FilmID  int `json:"film_id" example:"3"`
+	GenreID int `json:"genre_id" example:"2"`
 }
 
 //swagger:model
 type FilmResponse struct {
-	Id   int    `json:"id" example:"3"`
-	Name string `json:"name" example:"Затмение"`
+	Id         int    `json:"id" example:"3"`
+	Name       string `json:"name" example:"Затмение"`
+	CreditType string `json:"credit_type,omitempty" example:"actor"`
+	Character  string `json:"character,omitempty" example:"Гриша"`
 }
 
 //swagger:model
@@ -46,13 +206,206 @@ type ActorResponse struct {
 	FatherName string         `json:"fathername" example:"Александрович"`
 	BirthDate  string         `json:"birthdate" example:"19770306"`
 	Sex        string         `json:"sex" example:"m"`
-	Films      []FilmResponse `json:"films" example:"[{\"id\":3,\"name\":\"Затмение\"}]"`
+	Films      []FilmResponse `json:"films" example:"[{\"id\":3,\"name\":\"Затмение\",\"credit_type\":\"actor\",\"character\":\"Гриша\"}]"`
+}
+
+// Review is a user's score of a film with an optional text.
+// Every user has at most one review per film.
+//
+//swagger:model
+type Review struct {
+	Id        int       `json:"id" example:"1"`
+	FilmID    int       `json:"film_id" example:"3"`
+	Login     string    `json:"login" example:"john_doe"`
+	Score     int       `json:"score" example:"8"`
+	Text      string    `json:"text,omitempty" example:"Отличный фильм"`
+	CreatedAt time.Time `json:"created_at" example:"2024-03-16T12:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2024-03-16T12:00:00Z"`
+}
+
+//swagger:model
+type ReviewList struct {
+	Reviews []Review `json:"reviews"`
+	Total   int      `json:"total" example:"12"`
+}
+
+// WatchlistFilm names a film of the watchlist of the current user.
+//
+//swagger:model
+type WatchlistFilm struct {
+	FilmID int `json:"film_id" binding:"required" example:"3"`
+}
+
+//swagger:model
+type WatchlistEntry struct {
+	Film    Film      `json:"film"`
+	AddedAt time.Time `json:"added_at" example:"2024-03-16T12:00:00Z"`
+}
+
+//swagger:model
+type Watchlist struct {
+	Films []WatchlistEntry `json:"films"`
+	Total int              `json:"total" example:"4"`
+}
+
+// WatchedFilm marks a film as watched on the date, today if it is empty.
+// Deleting with an empty date removes every viewing of the film.
+//
+//swagger:model
+type WatchedFilm struct {
+	FilmID int    `json:"film_id" binding:"required" example:"3"`
+	Date   string `json:"date" example:"20240316"`
+}
+
+//swagger:model
+type WatchedEntry struct {
+	Film Film   `json:"film"`
+	Date string `json:"date" example:"20240316"`
+}
+
+//swagger:model
+type WatchHistory struct {
+	Entries []WatchedEntry `json:"entries"`
+	Total   int            `json:"total" example:"7"`
+}
+
+// Collection is a named list of films curated by a user and shared by its slug.
+// Films are listed in the order set by the owner and only filled in for a single collection.
+//
+//swagger:model
+type Collection struct {
+	Id          int       `json:"id" example:"1"`
+	Slug        string    `json:"slug" example:"luchshee-s-kianu-3f9a1c"`
+	Login       string    `json:"login" example:"john_doe"`
+	Name        string    `json:"name" example:"Лучшее с Киану"`
+	Description string    `json:"description,omitempty" example:"Фильмы, которые стоит пересмотреть"`
+	Public      bool      `json:"public" example:"true"`
+	FilmCount   int       `json:"film_count" example:"2"`
+	Films       []Film    `json:"films,omitempty"`
+	CreatedAt   time.Time `json:"created_at" example:"2024-03-16T12:00:00Z"`
+	UpdatedAt   time.Time `json:"updated_at" example:"2024-03-16T12:00:00Z"`
+}
+
+//swagger:model
+type CollectionInput struct {
+	Name        string `json:"name" binding:"required" example:"Лучшее с Киану"`
+	Description string `json:"description" example:"Фильмы, которые стоит пересмотреть"`
+	Public      bool   `json:"public" example:"true"`
+}
+
+// CollectionUpdate holds the collection fields to change, nil fields are kept.
+//
+//swagger:model
+type CollectionUpdate struct {
+	Name        *string `json:"name" example:"Новогодние фильмы"`
+	Description *string `json:"description" example:""`
+	Public      *bool   `json:"public" example:"false"`
+}
+
+//swagger:model
+type CollectionFilm struct {
+	FilmID int `json:"film_id" binding:"required" example:"3"`
+}
+
+//swagger:model
+type CollectionOrder struct {
+	FilmIDs []int `json:"film_ids" binding:"required" example:"3,1,2"`
+}
+
+// CollectionListQuery describes one page of the public collections,
+// Query filters by a piece of the name and Login by the owner.
+//
+//swagger:model
+type CollectionListQuery struct {
+	Query  string `json:"query" form:"query" example:"Киану"`
+	Login  string `json:"login" form:"login" example:"john_doe"`
+	Limit  int    `json:"limit" form:"limit" example:"20"`
+	Offset int    `json:"offset" form:"offset" example:"0"`
+}
+
+//swagger:model
+type CollectionList struct {
+	Collections []Collection `json:"collections"`
+	Total       int          `json:"total" example:"5"`
+}
+
+// Recommendation is a suggested film along with its score and the reasons it was suggested for.
+//
+//swagger:model
+type Recommendation struct {
+	Film    Film     `json:"film"`
+	Score   float64  `json:"score" example:"7.5"`
+	Reasons []string `json:"reasons" example:"same cast: Киану Ривз,same genres: боевик"`
+}
+
+//swagger:model
+type RecommendationList struct {
+	Films []Recommendation `json:"films"`
+}
+
+// CreditedPerson is a person credited in a film as recommendations compare films by.
+type CreditedPerson struct {
+	ActorID    int
+	FullName   string
+	CreditType string
+}
+
+// FilmFeatures is a film along with the people credited in it, its genres are in Film.Genres.
+type FilmFeatures struct {
+	Film   Film
+	People []CreditedPerson
+}
+
+// UserSignal is what the user did with a film: rated it if Score is not zero and watched it if Watched is set.
+type UserSignal struct {
+	FilmID  int
+	Score   int
+	Watched bool
+}
+
+// FilmHit is a film found by the full-text search. Snippet is an HTML-escaped fragment
+// of the name and description with the matched words wrapped in <b></b>.
+//
+//swagger:model
+type FilmHit struct {
+	Film
+	Snippet string  `json:"snippet" example:"<b>Затмение</b>. Самозванец участвует в шоу экстрасенсов"`
+	Rank    float32 `json:"rank" example:"0.6"`
+}
+
+//swagger:model
+type ActorHit struct {
+	Id       int     `json:"id" example:"2"`
+	FullName string  `json:"full_name" example:"Киану Ривз"`
+	Rank     float32 `json:"rank" example:"0.8"`
+}
+
+//swagger:model
+type SearchResult struct {
+	Actors []ActorHit `json:"actors"`
+	Films  []FilmHit  `json:"films"`
+}
+
+// ActorListQuery describes one page of the actor listing, Name filters by a piece of the full name.
+//
+//swagger:model
+type ActorListQuery struct {
+	Name   string `json:"name" form:"name" example:"Ривз"`
+	Limit  int    `json:"limit" form:"limit" example:"20"`
+	Offset int    `json:"offset" form:"offset" example:"0"`
+}
+
+//swagger:model
+type ActorList struct {
+	Actors []ActorResponse `json:"actors"`
+	Total  int             `json:"total" example:"3"`
 }
 
 //swagger:model
 type JSONFragment struct {
 	Key      string `json:"key" example:"actor"`
 	Fragment string `json:"fragment" example:"иану"`
+	Genre    string `json:"genre" example:""`
 }
 
 //swagger:model
@@ -61,6 +414,52 @@ type KeySort struct {
 	Value string `json:"value" example:"name"`
 }
 
+//swagger:model
+type SortKey struct {
+	Field string `json:"field" example:"rating"`
+	Order string `json:"order" example:"desc"`
+}
+
+// FilmListQuery describes one page of the film listing.
+// Cursor and Offset are mutually exclusive, Cursor is the NextCursor of the previous page.
+//
+//swagger:model
+type FilmListQuery struct {
+	Sort       []SortKey `json:"sort" form:"-"`
+	Limit      int       `json:"limit" form:"limit" example:"20"`
+	Offset     int       `json:"offset" form:"offset" example:"0"`
+	Cursor     string    `json:"cursor" form:"cursor" example:""`
+	RatingFrom *float32  `json:"rating_from" form:"rating_from" example:"5"`
+	RatingTo   *float32  `json:"rating_to" form:"rating_to" example:"9"`
+	DateFrom   string    `json:"date_from" form:"date_from" example:"20100101"`
+	DateTo     string    `json:"date_to" form:"date_to" example:"20201231"`
+	Genre      string    `json:"genre" form:"genre" example:"комедия"`
+}
+
+//swagger:model
+type FilmList struct {
+	Films      []Film `json:"films"`
+	Total      int    `json:"total" example:"3"`
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJpZCI6M30"`
+}
+
+// CheckResult is the outcome of a readiness check. Why a check failed is only logged,
+// the probe answers unauthenticated callers.
+//
+//swagger:model
+type CheckResult struct {
+	Status     string `json:"status" example:"ok"`
+	DurationMs int64  `json:"duration_ms" example:"2"`
+}
+
+// Health is the state of the service, "ok" or "unavailable", along with the checks it was told by.
+//
+//swagger:model
+type Health struct {
+	Status string                 `json:"status" example:"ok"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
 //swagger:model
 type StatusOKMessage struct {
 	Message string `json:"status" example:"ok"`
@@ -74,6 +473,18 @@ type StatusBadRequestMessage struct {
 //swagger:model
 type StatusUnauthorizedMessage struct {
 	Message string `json:"message" example:"Error: unauthorized"`
+	Code    string `json:"code,omitempty" example:"token_expired"`
+}
+
+//swagger:model
+type StatusForbiddenMessage struct {
+	Message string `json:"message" example:"Error: forbidden"`
+	Code    string `json:"code,omitempty" example:"forbidden"`
+}
+
+//swagger:model
+type StatusTooManyRequestsMessage struct {
+	Message string `json:"message" example:"Error: too many requests"`
 }
 
 //swagger:model
@@ -85,8 +496,3 @@ type StatusNotFoundMessage struct {
 type StatusInternalServerErrorMessage struct {
 	Message string `json:"message" example:"Error: internal server error"`
 }
-
-//swagger:model
-var (
-	Secret = []byte("gBElG5NThZSyeBysksiwusdbqlnwkqhrbv10481u592g")
-)
@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	st "VK_app/internal/structures"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+// minSecretLength is the shortest accepted HS256 secret, in bytes.
+const minSecretLength = 32
+
+// KeyConfig describes a signing key: its id, its algorithm and the file holding it.
+//
+// HS256 files hold the raw secret, RS256 and EdDSA files hold a PEM encoded private key,
+// or a public key for keys that only verify tokens signed before a rotation.
+// An HS256 secret may be given inline in Secret instead of a file.
+type KeyConfig struct {
+	ID        string
+	Algorithm string
+	File      string
+	Secret    string
+}
+
+// key is a loaded signing or verification key.
+type key struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// KeySet holds the key signing new tokens and every key tokens are still verified with.
+// Tokens carry the id of their key in the kid header, so adding a key and switching the
+// signing one rotates keys without invalidating tokens already issued.
+type KeySet struct {
+	signing *key
+	keys    map[string]*key
+	ordered []*key
+	methods []string
+}
+
+// LoadKeySet reads the configured keys, signingID selects the key signing new tokens.
+func LoadKeySet(configs []KeyConfig, signingID string) (*KeySet, error) {
+	if len(configs) == 0 {
+		return nil, errors.New("no signing keys configured")
+	}
+	ks := &KeySet{keys: map[string]*key{}}
+	seen := map[string]bool{}
+	for _, config := range configs {
+		if _, ok := ks.keys[config.ID]; ok || config.ID == "" {
+			return nil, fmt.Errorf("key id %q is empty or duplicated", config.ID)
+		}
+		k, err := loadKey(config)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", config.ID, err)
+		}
+		ks.keys[k.id] = k
+		ks.ordered = append(ks.ordered, k)
+		if !seen[k.method.Alg()] {
+			seen[k.method.Alg()] = true
+			ks.methods = append(ks.methods, k.method.Alg())
+		}
+	}
+	signing, ok := ks.keys[signingID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q is not configured", signingID)
+	}
+	if signing.signKey == nil {
+		return nil, fmt.Errorf("signing key %q has no private key", signingID)
+	}
+	ks.signing = signing
+	return ks, nil
+}
+
+// loadKey reads a key file according to the algorithm.
+func loadKey(config KeyConfig) (*key, error) {
+	data := []byte(config.Secret)
+	if config.File != "" {
+		var err error
+		if data, err = os.ReadFile(config.File); err != nil {
+			return nil, err
+		}
+	}
+	k := &key{id: config.ID}
+	switch config.Algorithm {
+	case HS256:
+		secret := []byte(strings.TrimSpace(string(data)))
+		if len(secret) < minSecretLength {
+			return nil, fmt.Errorf("HS256 secret must be at least %d bytes", minSecretLength)
+		}
+		k.method, k.signKey, k.verifyKey = jwt.SigningMethodHS256, secret, secret
+	case RS256:
+		k.method = jwt.SigningMethodRS256
+		if isPrivatePEM(data) {
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			k.signKey, k.verifyKey = private, &private.PublicKey
+		} else {
+			public, err := jwt.ParseRSAPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			k.verifyKey = public
+		}
+	case EdDSA:
+		k.method = jwt.SigningMethodEdDSA
+		if isPrivatePEM(data) {
+			private, err := jwt.ParseEdPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			k.signKey, k.verifyKey = private, private.(ed25519.PrivateKey).Public()
+		} else {
+			public, err := jwt.ParseEdPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			k.verifyKey = public
+		}
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q, use %s, %s or %s", config.Algorithm, HS256, RS256, EdDSA)
+	}
+	return k, nil
+}
+
+// isPrivatePEM reports whether the PEM data holds a private key.
+func isPrivatePEM(data []byte) bool {
+	block, _ := pem.Decode(data)
+	return block != nil && strings.Contains(block.Type, "PRIVATE")
+}
+
+// sign signs the claims with the signing key and sets its id in the kid header.
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	token.Header["kid"] = ks.signing.id
+	return token.SignedString(ks.signing.signKey)
+}
+
+// keyfunc returns the verification key named by the kid header of the token.
+// The algorithm of the token must be the one of the key, which rules out
+// verifying an RS256 public key as an HS256 secret.
+func (ks *KeySet) keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	k, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("key %s does not sign %s tokens", kid, token.Method.Alg())
+	}
+	return k.verifyKey, nil
+}
+
+// JWKS returns the public keys of the set in the JSON Web Key Set format.
+// HS256 secrets are never published.
+func (ks *KeySet) JWKS() st.JWKS {
+	set := st.JWKS{Keys: []st.JWK{}}
+	for _, k := range ks.ordered {
+		switch public := k.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, st.JWK{
+				KeyType:   "RSA",
+				KeyID:     k.id,
+				Use:       "sig",
+				Algorithm: RS256,
+				N:         base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, st.JWK{
+				KeyType:   "OKP",
+				KeyID:     k.id,
+				Use:       "sig",
+				Algorithm: EdDSA,
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+	return set
+}
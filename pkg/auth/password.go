@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// PasswordCost is the bcrypt cost of new password hashes, older hashes are upgraded on login.
+	PasswordCost = 12
+	// MinPasswordLength is the shortest accepted password, in characters.
+	MinPasswordLength = 10
+	// MaxPasswordBytes is the longest accepted password, bcrypt ignores anything past it.
+	MaxPasswordBytes = 72
+)
+
+// ErrWeakPassword is returned for passwords breaking the password policy.
+var ErrWeakPassword = errors.New("weak password")
+
+// commonPasswords are passwords long enough for the policy yet guessed first.
+var commonPasswords = map[string]bool{
+	"1234567890":   true,
+	"qwertyuiop":   true,
+	"password123":  true,
+	"password1234": true,
+	"qwerty12345":  true,
+	"1q2w3e4r5t":   true,
+	"iloveyou123":  true,
+	"admin12345":   true,
+	"welcome123":   true,
+	"йцукенгшщз":   true,
+}
+
+// ValidatePassword checks the password of the user against the password policy: it must be
+// 10 to 72 bytes long, mix letters with digits or symbols, not contain the login and not be a common password.
+func ValidatePassword(password, login string) error {
+	if len([]rune(password)) < MinPasswordLength {
+		return fmt.Errorf("%w: password must be at least %d characters long", ErrWeakPassword, MinPasswordLength)
+	}
+	if len(password) > MaxPasswordBytes {
+		return fmt.Errorf("%w: password must be at most %d bytes long", ErrWeakPassword, MaxPasswordBytes)
+	}
+	var letters, others bool
+	for _, r := range password {
+		if unicode.IsLetter(r) {
+			letters = true
+		} else if !unicode.IsSpace(r) {
+			others = true
+		}
+	}
+	if !letters || !others {
+		return fmt.Errorf("%w: password must contain letters and digits or symbols", ErrWeakPassword)
+	}
+	lower := strings.ToLower(password)
+	if login != "" && strings.Contains(lower, strings.ToLower(login)) {
+		return fmt.Errorf("%w: password must not contain the login", ErrWeakPassword)
+	}
+	if commonPasswords[lower] {
+		return fmt.Errorf("%w: password is too common", ErrWeakPassword)
+	}
+	return nil
+}
+
+// HashPassword returns the bcrypt hash of the password.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), PasswordCost)
+	return string(hash), err
+}
+
+// NeedsRehash reports whether the hash uses a lower cost than PasswordCost.
+func NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost < PasswordCost
+}
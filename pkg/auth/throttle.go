@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// Defaults of the login guard.
+const (
+	// LoginFreeAttempts failures of a login are allowed before it gets locked.
+	LoginFreeAttempts = 5
+	// IPFreeAttempts failures from an address are allowed before it gets locked.
+	IPFreeAttempts = 20
+	// ResetFreeRequests password reset requests of a login are served before it gets throttled.
+	ResetFreeRequests = 3
+	// BaseLockout is the first lockout, every next failure doubles it.
+	BaseLockout = time.Second
+	// MaxLockout caps the lockout.
+	MaxLockout = 15 * time.Minute
+	// FailureWindow is how long failures are remembered after the last one.
+	FailureWindow = time.Hour
+)
+
+// sweepSize is the number of tracked keys over which forgotten ones are dropped.
+const sweepSize = 10000
+
+// attempts are the recent failures of a key.
+type attempts struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// Throttle tracks failures per key and locks a key out with an exponential backoff
+// once it exceeds its free attempts. It keeps its state in memory.
+type Throttle struct {
+	mu      sync.Mutex
+	entries map[string]*attempts
+	free    int
+	base    time.Duration
+	max     time.Duration
+	window  time.Duration
+	now     func() time.Time
+}
+
+// NewThrottle returns a Throttle allowing free failures, then locking for base, 2*base, ... up to max.
+// Failures are forgotten after window without a new one.
+func NewThrottle(free int, base, max, window time.Duration) *Throttle {
+	return &Throttle{
+		entries: map[string]*attempts{},
+		free:    free,
+		base:    base,
+		max:     max,
+		window:  window,
+		now:     time.Now,
+	}
+}
+
+// Wait returns how long the key stays locked, zero if it is not.
+func (t *Throttle) Wait(key string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a, ok := t.entries[key]
+	if !ok {
+		return 0
+	}
+	if wait := a.lockedUntil.Sub(t.now()); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// Fail records a failure of the key and returns the lockout it causes, zero within the free attempts.
+func (t *Throttle) Fail(key string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	if len(t.entries) > sweepSize {
+		t.sweep(now)
+	}
+	a, ok := t.entries[key]
+	if !ok || now.Sub(a.lastFailure) > t.window {
+		a = &attempts{}
+		t.entries[key] = a
+	}
+	a.failures++
+	a.lastFailure = now
+	excess := a.failures - t.free
+	if excess <= 0 {
+		return 0
+	}
+	lockout := t.max
+	if excess <= 30 && t.base<<(excess-1) < t.max {
+		lockout = t.base << (excess - 1)
+	}
+	a.lockedUntil = now.Add(lockout)
+	return lockout
+}
+
+// Reset forgets the failures of the key and unlocks it.
+func (t *Throttle) Reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, key)
+}
+
+// sweep drops the keys whose failures are forgotten.
+func (t *Throttle) sweep(now time.Time) {
+	for key, a := range t.entries {
+		if now.Sub(a.lastFailure) > t.window && !a.lockedUntil.After(now) {
+			delete(t.entries, key)
+		}
+	}
+}
+
+// LoginGuard protects the login endpoint from password guessing by throttling
+// both the attacked login and the address the attempts come from.
+type LoginGuard struct {
+	logins *Throttle
+	ips    *Throttle
+}
+
+// NewLoginGuard returns a LoginGuard with the default limits.
+func NewLoginGuard() *LoginGuard {
+	return &LoginGuard{
+		logins: NewThrottle(LoginFreeAttempts, BaseLockout, MaxLockout, FailureWindow),
+		ips:    NewThrottle(IPFreeAttempts, BaseLockout, MaxLockout, FailureWindow),
+	}
+}
+
+// Wait returns how long login attempts for the login from the address stay refused.
+func (g *LoginGuard) Wait(login, ip string) time.Duration {
+	return max(g.logins.Wait(login), g.ips.Wait(ip))
+}
+
+// Fail records a failed attempt for the login from the address.
+func (g *LoginGuard) Fail(login, ip string) {
+	g.logins.Fail(login)
+	g.ips.Fail(ip)
+}
+
+// Succeed forgets the failures of the login. Failures of the address are kept,
+// so one valid account does not let an address keep guessing others.
+func (g *LoginGuard) Succeed(login string) {
+	g.logins.Reset(login)
+}
+
+// Unlock lifts the lockout of the login.
+func (g *LoginGuard) Unlock(login string) {
+	g.logins.Reset(login)
+}
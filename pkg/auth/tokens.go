@@ -0,0 +1,98 @@
+// Package auth issues and verifies the tokens of the film library.
+//
+// A login starts a session. The client gets a short-lived JWT access token bound to the
+// session and an opaque refresh token, which is exchanged for a new pair on every refresh.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Lifetimes are the lifetimes of the tokens.
+type Lifetimes struct {
+	// Access is the lifetime of an access token.
+	Access time.Duration
+	// Refresh is the lifetime of a refresh token, a session unused for that long expires.
+	Refresh time.Duration
+	// Reset is the lifetime of a password reset token.
+	Reset time.Duration
+}
+
+// ErrTokenExpired is returned by ParseAccessToken for a valid token past its expiration.
+var ErrTokenExpired = jwt.ErrTokenExpired
+
+// Claims are the claims of an access token.
+type Claims struct {
+	Login     string `json:"login"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// NewAccessToken signs an access token of the user for the session, valid for ttl.
+func (ks *KeySet) NewAccessToken(login, role, sessionID string, now time.Time, ttl time.Duration) (string, error) {
+	return ks.sign(Claims{
+		Login:     login,
+		Role:      role,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+}
+
+// ParseAccessToken verifies the signature and the expiration of the access token and returns its claims.
+func (ks *KeySet) ParseAccessToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, ks.keyfunc, jwt.WithValidMethods(ks.methods), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if claims.Login == "" || claims.SessionID == "" {
+		return nil, errors.New("token has no login or session")
+	}
+	return claims, nil
+}
+
+// NewSessionID returns a random session id.
+func NewSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// NewRefreshToken returns a random refresh token along with the hash to store.
+func NewRefreshToken() (token, hash string, err error) {
+	return newOpaqueToken()
+}
+
+// NewResetToken returns a random password reset token along with the hash to store.
+func NewResetToken() (token, hash string, err error) {
+	return newOpaqueToken()
+}
+
+// newOpaqueToken returns a random token along with its hash.
+func newOpaqueToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the hash a refresh or a password reset token is stored and looked up by.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"VK_app/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetActor godoc
+// @Summary GetActor
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Get an actor along with the filmography.
+// @ID get-actor
+// @Produce json
+// @Param id path int true "actor id"
+// @Success 200 {object} st.ActorResponse "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 404 {object} st.StatusNotFoundMessage "not found"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/actors/{id} [get]
+func (h *Handler) GetActor(c *gin.Context) {
+	h.getActor(c)
+}
+
+// GetActorAdmin godoc
+// @Summary GetActorAdmin
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Get an actor along with the filmography.
+// @ID get-actor-admin
+// @Produce json
+// @Param id path int true "actor id"
+// @Success 200 {object} st.ActorResponse "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 404 {object} st.StatusNotFoundMessage "not found"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/actors/{id} [get]
+func (h *Handler) GetActorAdmin(c *gin.Context) {
+	h.getActor(c)
+}
+
+func (h *Handler) getActor(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Wrong actor id"})
+		return
+	}
+	actor, err := h.actors.GetActor(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Actor not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, actor)
+}
@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	st "VK_app/internal/structures"
+	"VK_app/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// slugAttempts is how many random slugs are tried before giving up on creating a collection.
+const slugAttempts = 3
+
+// ListCollections godoc
+// @Summary ListCollections
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Browse the public collections of all users, the latest updated first.
+// @ID list-collections
+// @Produce json
+// @Param query query string false "piece of the collection name"
+// @Param login query string false "owner of the collections"
+// @Param limit query int false "page size, 20 by default, at most 100"
+// @Param offset query int false "number of collections to skip"
+// @Success 200 {object} st.CollectionList "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/collections [get]
+func (h *Handler) ListCollections(c *gin.Context) {
+	var query st.CollectionListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	list, err := h.collections.ListPublicCollections(c.Request.Context(), query)
+	respondCollections(c, list, err)
+}
+
+// GetMyCollections godoc
+// @Summary GetMyCollections
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Get the public and private collections of the current user, the latest updated first.
+// @ID get-my-collections
+// @Produce json
+// @Param limit query int false "page size, 20 by default, at most 100"
+// @Param offset query int false "number of collections to skip"
+// @Success 200 {object} st.CollectionList "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/me/collections [get]
+func (h *Handler) GetMyCollections(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	limit, offset, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	list, err := h.collections.GetUserCollections(c.Request.Context(), principal.Login, limit, offset)
+	respondCollections(c, list, err)
+}
+
+func respondCollections(c *gin.Context, list st.CollectionList, err error) {
+	if errors.Is(err, repository.ErrInvalidQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// PostCollection godoc
+// @Summary PostCollection
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Create an empty collection. The answer holds the slug the collection is shared by.
+// @ID post-collection
+// @Accept json
+// @Produce json
+// @Param input body st.CollectionInput true "name, description and visibility of the collection"
+// @Success 201 {object} st.StatusOKMessage "collection was successfully created"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/collections [post]
+func (h *Handler) PostCollection(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	var input st.CollectionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	collection := st.Collection{Login: principal.Login, Name: input.Name, Description: input.Description, Public: input.Public}
+	var err error
+	for i := 0; i < slugAttempts; i++ {
+		if collection.Slug, err = repository.NewSlug(input.Name); err != nil {
+			break
+		}
+		if err = h.collections.CreateCollection(c.Request.Context(), collection); !errors.Is(err, repository.ErrAlreadyExists) {
+			break
+		}
+	}
+	if errors.Is(err, repository.ErrInvalidCollection) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "created", "slug": collection.Slug})
+}
+
+// GetCollection godoc
+// @Summary GetCollection
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Get a collection along with its films in order. Private collections are only shown to their owner.
+// @ID get-collection
+// @Produce json
+// @Param slug path string true "collection slug"
+// @Success 200 {object} st.Collection "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Failure 404 {object} st.StatusNotFoundMessage "collection not found"
+// @Router /filmlibrary/collections/{slug} [get]
+func (h *Handler) GetCollection(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	collection, err := h.collections.GetCollection(c.Request.Context(), c.Param("slug"), principal.Login)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, collection)
+}
+
+// UpdateCollection godoc
+// @Summary UpdateCollection
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Rename a collection of the current user, change its description or make it public or private. Omitted fields are kept.
+// @ID update-collection
+// @Accept json
+// @Produce json
+// @Param slug path string true "collection slug"
+// @Param input body st.CollectionUpdate true "collection fields to change"
+// @Success 200 {object} st.StatusOKMessage "collection was successfully updated"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Failure 404 {object} st.StatusNotFoundMessage "collection not found"
+// @Router /filmlibrary/collections/{slug} [patch]
+func (h *Handler) UpdateCollection(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	var update st.CollectionUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.collections.UpdateCollection(c.Request.Context(), principal.Login, c.Param("slug"), update)
+	respondCollectionChange(c, err, "updated")
+}
+
+// DeleteCollection godoc
+// @Summary DeleteCollection
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Delete a collection of the current user.
+// @ID delete-collection
+// @Produce json
+// @Param slug path string true "collection slug"
+// @Success 200 {object} st.StatusOKMessage "collection was successfully deleted"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Failure 404 {object} st.StatusNotFoundMessage "collection not found"
+// @Router /filmlibrary/collections/{slug} [delete]
+func (h *Handler) DeleteCollection(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	err := h.collections.DelCollection(c.Request.Context(), principal.Login, c.Param("slug"))
+	respondCollectionChange(c, err, "deleted")
+}
+
+// PostCollectionFilm godoc
+// @Summary PostCollectionFilm
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Append a film to a collection of the current user.
+// @ID post-collection-film
+// @Accept json
+// @Produce json
+// @Param slug path string true "collection slug"
+// @Param input body st.CollectionFilm true "film id"
+// @Success 201 {object} st.StatusOKMessage "film was successfully added"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Failure 404 {object} st.StatusNotFoundMessage "collection or film not found"
+// @Failure 409 {object} st.StatusBadRequestMessage "film is already in the collection"
+// @Router /filmlibrary/collections/{slug}/films [post]
+func (h *Handler) PostCollectionFilm(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	var film st.CollectionFilm
+	if err := c.ShouldBindJSON(&film); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.collections.AddCollectionFilm(c.Request.Context(), principal.Login, c.Param("slug"), film.FilmID)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Film is already in the collection"})
+		return
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection or film not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "created"})
+}
+
+// DeleteCollectionFilm godoc
+// @Summary DeleteCollectionFilm
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Remove a film from a collection of the current user.
+// @ID delete-collection-film
+// @Accept json
+// @Produce json
+// @Param slug path string true "collection slug"
+// @Param input body st.CollectionFilm true "film id"
+// @Success 200 {object} st.StatusOKMessage "film was successfully removed"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Failure 404 {object} st.StatusNotFoundMessage "collection or film not found"
+// @Router /filmlibrary/collections/{slug}/films [delete]
+func (h *Handler) DeleteCollectionFilm(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	var film st.CollectionFilm
+	if err := c.ShouldBindJSON(&film); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.collections.DelCollectionFilm(c.Request.Context(), principal.Login, c.Param("slug"), film.FilmID)
+	respondCollectionChange(c, err, "deleted")
+}
+
+// ReorderCollection godoc
+// @Summary ReorderCollection
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Put the films of a collection of the current user in a new order. The order must list every film of the collection once.
+// @ID reorder-collection
+// @Accept json
+// @Produce json
+// @Param slug path string true "collection slug"
+// @Param input body st.CollectionOrder true "film ids in the new order"
+// @Success 200 {object} st.StatusOKMessage "collection was successfully reordered"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Failure 404 {object} st.StatusNotFoundMessage "collection not found"
+// @Router /filmlibrary/collections/{slug}/order [put]
+func (h *Handler) ReorderCollection(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	var order st.CollectionOrder
+	if err := c.ShouldBindJSON(&order); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.collections.ReorderCollection(c.Request.Context(), principal.Login, c.Param("slug"), order.FilmIDs)
+	respondCollectionChange(c, err, "updated")
+}
+
+func respondCollectionChange(c *gin.Context, err error, message string) {
+	if errors.Is(err, repository.ErrInvalidCollection) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
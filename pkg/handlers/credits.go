@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	st "VK_app/internal/structures"
+	"VK_app/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateActorFilm godoc
+// @Summary UpdateActorFilm
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Update the character and the billing position of a credit.
+// @ID update-actor-film
+// @Accept json
+// @Produce json
+// @Param input body st.ActorFilm true "ActorFilm object for updating"
+// @Success 200 {object} st.StatusOKMessage "credit was successfully updated"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 404 {object} st.StatusNotFoundMessage "not found"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/actorsfilms [put]
+func (h *Handler) UpdateActorFilm(c *gin.Context) {
+	var actorfilm st.ActorFilm
+	if err := c.ShouldBindJSON(&actorfilm); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.actors.UpdateActorFilm(c.Request.Context(), actorfilm)
+	if errors.Is(err, repository.ErrInvalidCredit) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credit not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "updated"})
+}
+
+// DeleteActorFilm godoc
+// @Summary DeleteActorFilm
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Remove a credit of an actor in a film.
+// @ID delete-actor-film
+// @Accept json
+// @Produce json
+// @Param input body st.ActorFilm true "ActorFilm object for deleting"
+// @Success 200 {object} st.StatusOKMessage "credit was successfully deleted"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 404 {object} st.StatusNotFoundMessage "not found"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/actorsfilms [delete]
+func (h *Handler) DeleteActorFilm(c *gin.Context) {
+	var actorfilm st.ActorFilm
+	if err := c.ShouldBindJSON(&actorfilm); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.actors.DelActorFilm(c.Request.Context(), actorfilm)
+	if errors.Is(err, repository.ErrInvalidCredit) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credit not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// GetFilmCredits godoc
+// @Summary GetFilmCredits
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Get the cast and crew of a film.
+// @ID get-film-credits
+// @Produce json
+// @Param film_id query int true "film id"
+// @Success 200 {array} st.CreditResponse "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 404 {object} st.StatusNotFoundMessage "not found"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/actorsfilms [get]
+func (h *Handler) GetFilmCredits(c *gin.Context) {
+	filmID, err := strconv.Atoi(c.Query("film_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Wrong film id"})
+		return
+	}
+	err = h.films.CheckFilm(c.Request.Context(), filmID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Film not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	credits, err := h.actors.GetFilmCredits(c.Request.Context(), filmID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, credits)
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	st "VK_app/internal/structures"
+	"VK_app/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListFilms godoc
+// @Summary ListFilms
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Get a page of films filtered by rating, release date and genre and sorted by several keys.
+// @ID list-films
+// @Produce json
+// @Param sort query string false "comma separated sort keys with optional order: rating, name, date" example(rating:desc,name:asc)
+// @Param limit query int false "page size, 20 by default, at most 100"
+// @Param offset query int false "number of films to skip, can't be used with cursor"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param rating_from query number false "minimal rating"
+// @Param rating_to query number false "maximal rating"
+// @Param date_from query string false "earliest release date, YYYYMMDD"
+// @Param date_to query string false "latest release date, YYYYMMDD"
+// @Param genre query string false "genre name"
+// @Success 200 {object} st.FilmList "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/films [get]
+func (h *Handler) ListFilms(c *gin.Context) {
+	h.listFilms(c)
+}
+
+// ListFilmsAdmin godoc
+// @Summary ListFilmsAdmin
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Get a page of films filtered by rating, release date and genre and sorted by several keys.
+// @ID list-films-admin
+// @Produce json
+// @Param sort query string false "comma separated sort keys with optional order: rating, name, date" example(rating:desc,name:asc)
+// @Param limit query int false "page size, 20 by default, at most 100"
+// @Param offset query int false "number of films to skip, can't be used with cursor"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param rating_from query number false "minimal rating"
+// @Param rating_to query number false "maximal rating"
+// @Param date_from query string false "earliest release date, YYYYMMDD"
+// @Param date_to query string false "latest release date, YYYYMMDD"
+// @Param genre query string false "genre name"
+// @Success 200 {object} st.FilmList "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/films [get]
+func (h *Handler) ListFilmsAdmin(c *gin.Context) {
+	h.listFilms(c)
+}
+
+func (h *Handler) listFilms(c *gin.Context) {
+	var query st.FilmListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sort, err := parseSort(c.Query("sort"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.Sort = sort
+	list, err := h.films.ListFilms(c.Request.Context(), query)
+	if errors.Is(err, repository.ErrInvalidQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// parseSort parses sort keys in the "rating:desc,name:asc" form.
+func parseSort(raw string) ([]st.SortKey, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var keys []st.SortKey
+	for _, part := range strings.Split(raw, ",") {
+		field, order, _ := strings.Cut(strings.TrimSpace(part), ":")
+		if field == "" {
+			return nil, fmt.Errorf("wrong sort key %q", part)
+		}
+		keys = append(keys, st.SortKey{Field: field, Order: order})
+	}
+	return keys, nil
+}
+
+// legacySortedFilms serves the deprecated filmssorted endpoints: the first page
+// of films sorted in descending order by the requested field.
+func (h *Handler) legacySortedFilms(ctx context.Context, sortKey st.KeySort) ([]st.Film, error) {
+	field := sortKey.Value
+	if field == "" {
+		field = "rating"
+	} else if field != "rating" && field != "name" {
+		field = "date"
+	}
+	list, err := h.films.ListFilms(ctx, st.FilmListQuery{
+		Sort:  []st.SortKey{{Field: field, Order: "desc"}},
+		Limit: repository.MaxLimit,
+	})
+	return list.Films, err
+}
+
+// GetFilm godoc
+// @Summary GetFilm
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Get a film along with its genres and cast.
+// @ID get-film
+// @Produce json
+// @Param id path int true "film id"
+// @Success 200 {object} st.FilmDetails "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 404 {object} st.StatusNotFoundMessage "not found"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/films/{id} [get]
+func (h *Handler) GetFilm(c *gin.Context) {
+	h.getFilm(c)
+}
+
+// GetFilmAdmin godoc
+// @Summary GetFilmAdmin
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Get a film along with its genres and cast.
+// @ID get-film-admin
+// @Produce json
+// @Param id path int true "film id"
+// @Success 200 {object} st.FilmDetails "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 404 {object} st.StatusNotFoundMessage "not found"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/films/{id} [get]
+func (h *Handler) GetFilmAdmin(c *gin.Context) {
+	h.getFilm(c)
+}
+
+func (h *Handler) getFilm(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Wrong film id"})
+		return
+	}
+	film, err := h.films.GetFilm(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Film not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	cast, err := h.actors.GetFilmCredits(c.Request.Context(), id)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	details := st.FilmDetails{Film: film, Cast: []st.CreditResponse{}}
+	details.Cast = append(details.Cast, cast...)
+	c.JSON(http.StatusOK, details)
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	st "VK_app/internal/structures"
+	"VK_app/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetGenres godoc
+// @Summary GetGenres
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Get all genres
+// @ID get-genres
+// @Produce json
+// @Success 200 {array} st.Genre "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/genres [get]
+func (h *Handler) GetGenres(c *gin.Context) {
+	h.getGenres(c)
+}
+
+// GetGenresAdmin godoc
+// @Summary GetGenresAdmin
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Get all genres
+// @ID get-genres-admin
+// @Produce json
+// @Success 200 {array} st.Genre "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/genres [get]
+func (h *Handler) GetGenresAdmin(c *gin.Context) {
+	h.getGenres(c)
+}
+
+func (h *Handler) getGenres(c *gin.Context) {
+	genres, err := h.genres.GetGenres(c.Request.Context())
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, genres)
+}
+
+// PostGenre godoc
+// @Summary AddGenre
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Add a new genre to the database.
+// @ID add-genre
+// @Accept json
+// @Produce json
+// @Param input body st.Genre true "Genre object for adding"
+// @Success 201 {object} st.StatusOKMessage "genre was successfully added"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Failure 409 {object} st.StatusBadRequestMessage "genre already exists"
+// @Router /filmlibrary/admin/genres [post]
+func (h *Handler) PostGenre(c *gin.Context) {
+	var genre st.Genre
+	if err := c.ShouldBindJSON(&genre); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if genre.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Genre name is empty"})
+		return
+	}
+	err := h.genres.AddGenre(c.Request.Context(), genre)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Genre already exists"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "created"})
+}
+
+// UpdateGenre godoc
+// @Summary UpdateGenre
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Rename a genre in the database.
+// @ID update-genre
+// @Accept json
+// @Produce json
+// @Param input body st.Genre true "Genre object for updating"
+// @Success 200 {object} st.StatusOKMessage "genre was successfully updated"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Failure 404 {object} st.StatusNotFoundMessage "genre not found"
+// @Failure 409 {object} st.StatusBadRequestMessage "genre already exists"
+// @Router /filmlibrary/admin/genre [put]
+func (h *Handler) UpdateGenre(c *gin.Context) {
+	var genre st.Genre
+	if err := c.ShouldBindJSON(&genre); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if genre.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Genre name is empty"})
+		return
+	}
+	err := h.genres.UpdateGenre(c.Request.Context(), genre)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Genre not found"})
+		return
+	}
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Genre already exists"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "updated"})
+}
+
+// DeleteGenre godoc
+// @Summary DeleteGenre
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Delete a genre from the database along with its links to films.
+// @ID delete-genre
+// @Accept json
+// @Produce json
+// @Param input body st.Genre true "Genre object for deleting"
+// @Success 200 {object} st.StatusOKMessage "genre was successfully deleted"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/genre [delete]
+func (h *Handler) DeleteGenre(c *gin.Context) {
+	var genre st.Genre
+	if err := c.ShouldBindJSON(&genre); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.genres.DelGenre(c.Request.Context(), genre.Id)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// PostFilmGenre godoc
+// @Summary AddFilmGenre
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Link a film to a genre.
+// @ID add-film-genre
+// @Accept json
+// @Produce json
+// @Param input body st.FilmGenre true "FilmGenre object for adding"
+// @Success 201 {object} st.StatusOKMessage "film genre was successfully added"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 404 {object} st.StatusNotFoundMessage "not found"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Failure 409 {object} st.StatusBadRequestMessage "film already has the genre"
+// @Router /filmlibrary/admin/filmsgenres [post]
+func (h *Handler) PostFilmGenre(c *gin.Context) {
+	var filmGenre st.FilmGenre
+	if err := c.ShouldBindJSON(&filmGenre); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.films.CheckFilm(c.Request.Context(), filmGenre.FilmID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Film not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	err = h.genres.CheckGenre(c.Request.Context(), filmGenre.GenreID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Genre not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	err = h.genres.AddFilmGenre(c.Request.Context(), filmGenre)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Film already has the genre"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "created"})
+}
+
+// DeleteFilmGenre godoc
+// @Summary DeleteFilmGenre
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Remove a genre from a film.
+// @ID delete-film-genre
+// @Accept json
+// @Produce json
+// @Param input body st.FilmGenre true "FilmGenre object for deleting"
+// @Success 200 {object} st.StatusOKMessage "film genre was successfully deleted"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/filmsgenres [delete]
+func (h *Handler) DeleteFilmGenre(c *gin.Context) {
+	var filmGenre st.FilmGenre
+	if err := c.ShouldBindJSON(&filmGenre); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.genres.DelFilmGenre(c.Request.Context(), filmGenre)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
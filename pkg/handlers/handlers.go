@@ -3,75 +3,191 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
-	l "VK_app/internal/dbconn"
 	st "VK_app/internal/structures"
-	"VK_app/pkg/postgresql"
+	"VK_app/pkg/auth"
+	"VK_app/pkg/middleware"
+	"VK_app/pkg/notify"
+	"VK_app/pkg/repository"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Repositories groups the storages the handlers work with.
+type Repositories struct {
+	Films           repository.FilmRepository
+	Actors          repository.ActorRepository
+	Genres          repository.GenreRepository
+	Reviews         repository.ReviewRepository
+	Users           repository.UserRepository
+	Roles           repository.RoleRepository
+	Sessions        repository.SessionRepository
+	Resets          repository.PasswordResetRepository
+	Watchlists      repository.WatchlistRepository
+	Collections     repository.CollectionRepository
+	Recommendations repository.RecommendationRepository
+}
+
+// Handler serves the film library API on top of the given repositories.
+type Handler struct {
+	keys            *auth.KeySet
+	lifetimes       auth.Lifetimes
+	guard           *auth.LoginGuard
+	dummyHash       string
+	films           repository.FilmRepository
+	actors          repository.ActorRepository
+	genres          repository.GenreRepository
+	reviews         repository.ReviewRepository
+	users           repository.UserRepository
+	roles           repository.RoleRepository
+	sessions        repository.SessionRepository
+	resets          repository.PasswordResetRepository
+	watchlists      repository.WatchlistRepository
+	collections     repository.CollectionRepository
+	recommendations repository.RecommendationRepository
+	notifier        notify.Notifier
+	// resetRequests throttles password reset requests per login.
+	resetRequests *auth.Throttle
+}
+
+// New returns a Handler working with the given repositories, signing tokens with the keys
+// for the lifetimes and delivering password reset tokens through the notifier.
+func New(repos Repositories, keys *auth.KeySet, lifetimes auth.Lifetimes, notifier notify.Notifier) *Handler {
+	return &Handler{
+		keys:            keys,
+		lifetimes:       lifetimes,
+		guard:           auth.NewLoginGuard(),
+		dummyHash:       newDummyHash(),
+		films:           repos.Films,
+		actors:          repos.Actors,
+		genres:          repos.Genres,
+		reviews:         repos.Reviews,
+		users:           repos.Users,
+		roles:           repos.Roles,
+		sessions:        repos.Sessions,
+		resets:          repos.Resets,
+		watchlists:      repos.Watchlists,
+		collections:     repos.Collections,
+		recommendations: repos.Recommendations,
+		notifier:        notifier,
+		resetRequests:   auth.NewThrottle(auth.ResetFreeRequests, time.Minute, auth.MaxLockout, auth.FailureWindow),
+	}
+}
+
+// newDummyHash returns a hash of the password cost no password matches,
+// compared against when the login is unknown.
+func newDummyHash() string {
+	hash, err := bcrypt.GenerateFromPassword([]byte("no password matches this hash"), auth.PasswordCost)
+	if err != nil {
+		log.Println(err)
+	}
+	return string(hash)
+}
+
+// tooManyAttempts refuses a login attempt of a locked login or address.
+func tooManyAttempts(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, try again later"})
+}
+
+// currentPrincipal returns the principal authenticated by the middleware.
+// Routes are always behind it, the 401 only guards against a misconfigured route.
+func currentPrincipal(c *gin.Context) (middleware.Principal, bool) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Println("no principal in the context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Access token is missing", "code": middleware.CodeTokenMissing})
+	}
+	return principal, ok
+}
+
 // Login godoc
 // @Summary Login
 // @Tags auth
-// @Description Login of a user or admin.
+// @Description Login of a user. It starts a session: the access token, valid for 15 minutes by default, is returned in the body and the Authorization header along with the refresh token. With cookie=true both tokens are set as HttpOnly cookies instead and the body carries the CSRF token to send in the X-CSRF-Token header of state-changing requests.
 // @ID login
 // @Accept json
 // @Produce json
 // @Param input body st.User true "login"
-// @Success 200 {object} st.StatusOKMessage "user was successfully logged in"
+// @Param cookie query bool false "keep the tokens in cookies"
+// @Success 200 {object} st.TokenResponse "user was successfully logged in"
 // @Failure 400 {object} st.StatusBadRequestMessage "bad request"
-// @Failure 404 {object} st.StatusNotFoundMessage "not found"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "login or password is wrong"
+// @Failure 403 {object} st.StatusForbiddenMessage "account is disabled"
+// @Failure 429 {object} st.StatusTooManyRequestsMessage "too many failed attempts, see Retry-After"
 // @Router /filmlibrary/login [post]
-func Login(c *gin.Context) {
+func (h *Handler) Login(c *gin.Context) {
 	var user st.User
 	if err := c.ShouldBindJSON(&user); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Wrong data format or missing data"})
 		return
 	}
 
-	var storedPassword string
-	row := l.Db.QueryRow("SELECT password FROM users WHERE login = $1", user.Login)
-	err := row.Scan(&storedPassword)
+	ip := c.ClientIP()
+	if wait := h.guard.Wait(user.Login, ip); wait > 0 {
+		tooManyAttempts(c, wait)
+		return
+	}
+	storedUser, err := h.users.GetUser(c.Request.Context(), user.Login)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	hash := storedUser.Password
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login is wrong"})
+		// Spend the same time on unknown logins as on wrong passwords.
+		hash = h.dummyHash
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(user.Password)) != nil || err != nil {
+		log.Printf("failed login of %s from %s\n", user.Login, ip)
+		h.guard.Fail(user.Login, ip)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login or password is wrong"})
 		return
 	}
+	h.guard.Succeed(user.Login)
+	if storedUser.Disabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+		return
+	}
+	if auth.NeedsRehash(storedUser.Password) {
+		h.upgradePasswordHash(c.Request.Context(), user)
+	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(user.Password))
+	sessionID, err := auth.NewSessionID()
 	if err != nil {
 		log.Println(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Password is wrong"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
 		return
 	}
-
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"login":          user.Login,
-		"hashedpassword": user.Password,
-		"role":           user.Role,
-		"exp":            time.Now().Add(time.Hour * 24).Unix(),
-	})
-
-	SignedToken, err := jwtToken.SignedString(st.Secret)
+	refreshToken, refreshHash, err := auth.NewRefreshToken()
 	if err != nil {
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
 		return
 	}
-
-	c.Header("Authorization", SignedToken)
-	c.JSON(http.StatusOK, gin.H{"message": "login was completed successfully"})
+	now := time.Now()
+	err = h.sessions.CreateSession(c.Request.Context(), st.Session{Id: sessionID, Login: storedUser.Login, CreatedAt: now},
+		st.RefreshToken{Hash: refreshHash, ExpiresAt: now.Add(h.lifetimes.Refresh)})
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	h.respondTokens(c, storedUser, sessionID, refreshToken, c.Query("cookie") == "true", "login was completed successfully")
 }
 
 // RegisterUser godoc
 // @Summary Register
 // @Tags auth
-// @Description Registration of a new user or admin.
+// @Description Registration of a new user. New users get the viewer role, an admin can grant another one later. The password must be 10 to 72 bytes long, mix letters with digits or symbols and not contain the login.
 // @ID register
 // @Accept json
 // @Produce json
@@ -80,17 +196,33 @@ func Login(c *gin.Context) {
 // @Failure 400 {object} st.StatusBadRequestMessage "bad request"
 // @Failure 404 {object} st.StatusNotFoundMessage "not found"
 // @Router /filmlibrary/registration [post]
-func RegisterUser(c *gin.Context) {
+func (h *Handler) RegisterUser(c *gin.Context) {
 	var user st.User
 	if err := c.ShouldBindJSON(&user); err != nil {
 		log.Println(err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Wrong data format or missing data"})
 		return
 	}
-	err := postgresql.AddUser(&user)
+	if err := auth.ValidatePassword(user.Password, user.Login); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	hashedPassword, err := auth.HashPassword(user.Password)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	user.Password = hashedPassword
+	user.Role = repository.DefaultRole
+	err = h.users.AddUser(c.Request.Context(), user)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
+		return
+	}
 	if err != nil {
 		log.Println(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Can't add user to database or user already exists"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Can't add user to database"})
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"message": "created"})
@@ -104,18 +236,13 @@ func RegisterUser(c *gin.Context) {
 // @ID add-film
 // @Accept json
 // @Produce json
-// @Param input body st.Film true "Film object for adding"
+// @Param input body st.Film true "Film object for adding, the rating is computed from reviews"
 // @Success 201 {object} st.StatusOKMessage "film was successfully added"
 // @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
 // @Failure 400 {object} st.StatusBadRequestMessage "bad request"
 // @Failure 404 {object} st.StatusNotFoundMessage "not found"
 // @Router /filmlibrary/admin/films [post]
-func PostFilm(c *gin.Context) {
-	if _, ok := c.Get("isAuthorizedAdmin"); !ok {
-		log.Println("Unauthorized admin")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized admin access denied"})
-		return
-	}
+func (h *Handler) PostFilm(c *gin.Context) {
 	var film st.Film
 	if err := c.ShouldBindJSON(&film); err != nil {
 		log.Println(err)
@@ -123,7 +250,7 @@ func PostFilm(c *gin.Context) {
 		return
 	}
 
-	err := postgresql.AddFilm(film)
+	err := h.films.AddFilm(c.Request.Context(), film)
 	if err != nil {
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -147,13 +274,9 @@ func PostFilm(c *gin.Context) {
 // @Failure 400 {object} st.StatusBadRequestMessage "bad request"
 // @Failure 404 {object} st.StatusNotFoundMessage "not found"
 // @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
 // @Router /filmlibrary/admin/actors [post]
-func PostActor(c *gin.Context) {
-	if _, ok := c.Get("isAuthorizedAdmin"); !ok {
-		log.Println("Unauthorized admin")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized admin access denied"})
-		return
-	}
+func (h *Handler) PostActor(c *gin.Context) {
 	var actor st.Actor
 	if err := c.ShouldBindJSON(&actor); err != nil {
 		log.Println(err)
@@ -161,7 +284,7 @@ func PostActor(c *gin.Context) {
 		return
 	}
 
-	err := postgresql.AddActor(actor)
+	err := h.actors.AddActor(c.Request.Context(), actor)
 	if err != nil {
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -175,7 +298,7 @@ func PostActor(c *gin.Context) {
 // @Summary AddActorFilm
 // @Security AdminKeyAuth
 // @Tags Admin Functions
-// @Description Add a new actor film to the database.
+// @Description Credit an actor in a film. The credit type is "actor" by default, the character and the billing position are optional.
 // @ID add-actor-film
 // @Accept json
 // @Produce json
@@ -185,32 +308,44 @@ func PostActor(c *gin.Context) {
 // @Failure 400 {object} st.StatusBadRequestMessage "bad request"
 // @Failure 404 {object} st.StatusNotFoundMessage "not found"
 // @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
 // @Router /filmlibrary/admin/actorsfilms [post]
-func PostActorFilm(c *gin.Context) {
-	if _, ok := c.Get("isAuthorizedAdmin"); !ok {
-		log.Println("Unauthorized admin")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized admin access denied"})
-		return
-	}
+func (h *Handler) PostActorFilm(c *gin.Context) {
 	var actorfilm st.ActorFilm
 	if err := c.ShouldBindJSON(&actorfilm); err != nil {
 		log.Println(err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err := postgresql.CheckActor(actorfilm.ActorID)
+	err := h.actors.CheckActor(c.Request.Context(), actorfilm.ActorID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Actor not found"})
+		return
+	}
 	if err != nil {
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	err = postgresql.CheckFilm(actorfilm.FilmID)
+	err = h.films.CheckFilm(c.Request.Context(), actorfilm.FilmID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Film not found"})
+		return
+	}
 	if err != nil {
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	err = postgresql.AddActorFilm(actorfilm)
+	err = h.actors.AddActorFilm(c.Request.Context(), actorfilm)
+	if errors.Is(err, repository.ErrInvalidCredit) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Actor already has this credit in the film"})
+		return
+	}
 	if err != nil {
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -233,20 +368,16 @@ func PostActorFilm(c *gin.Context) {
 // @Failure 400 {object} st.StatusBadRequestMessage "bad request"
 // @Failure 404 {object} st.StatusNotFoundMessage "not found"
 // @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
 // @Router /filmlibrary/admin/actor [put]
-func UpdateActor(c *gin.Context) {
-	if _, ok := c.Get("isAuthorizedAdmin"); !ok {
-		log.Println("Unauthorized admin")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized admin access denied"})
-		return
-	}
+func (h *Handler) UpdateActor(c *gin.Context) {
 	var actor st.Actor
 	if err := c.ShouldBindJSON(&actor); err != nil {
 		log.Println(err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err := postgresql.UpdateActor(actor)
+	err := h.actors.UpdateActor(c.Request.Context(), actor)
 	if err != nil {
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -269,20 +400,16 @@ func UpdateActor(c *gin.Context) {
 // @Failure 400 {object} st.StatusBadRequestMessage "bad request"
 // @Failure 404 {object} st.StatusNotFoundMessage "not found"
 // @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
 // @Router /filmlibrary/admin/actor [delete]
-func DeleteActor(c *gin.Context) {
-	if _, ok := c.Get("isAuthorizedAdmin"); !ok {
-		log.Println("Unauthorized admin")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized admin access denied"})
-		return
-	}
+func (h *Handler) DeleteActor(c *gin.Context) {
 	var actor st.Actor
 	if err := c.ShouldBindJSON(&actor); err != nil {
 		log.Println(err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err := postgresql.DelActor(actor.Id)
+	err := h.actors.DelActor(c.Request.Context(), actor.Id)
 	if err != nil {
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -300,26 +427,22 @@ func DeleteActor(c *gin.Context) {
 // @ID update-film
 // @Accept json
 // @Produce json
-// @Param input body st.Film true "Film object for updating"
+// @Param input body st.Film true "Film object for updating, the rating is computed from reviews"
 // @Success 200 {object} st.StatusOKMessage "film was successfully updated"
 // @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
 // @Failure 400 {object} st.StatusBadRequestMessage "bad request"
 // @Failure 404 {object} st.StatusNotFoundMessage "not found"
 // @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
 // @Router /filmlibrary/admin/film [put]
-func UpdateFilm(c *gin.Context) {
-	if _, ok := c.Get("isAuthorizedAdmin"); !ok {
-		log.Println("Unauthorized admin")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized admin access denied"})
-		return
-	}
+func (h *Handler) UpdateFilm(c *gin.Context) {
 	var film st.Film
 	if err := c.ShouldBindJSON(&film); err != nil {
 		log.Println(err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err := postgresql.UpdateFilm(film)
+	err := h.films.UpdateFilm(c.Request.Context(), film)
 	if err != nil {
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -343,19 +466,14 @@ func UpdateFilm(c *gin.Context) {
 // @Failure 404 {object} st.StatusNotFoundMessage
 // @Failure 401 {object} st.StatusUnauthorizedMessage
 // @Router /filmlibrary/admin/film [delete]
-func DeleteFilm(c *gin.Context) {
-	if _, ok := c.Get("isAuthorizedAdmin"); !ok {
-		log.Println("Unauthorized admin")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized admin access denied"})
-		return
-	}
+func (h *Handler) DeleteFilm(c *gin.Context) {
 	var film st.Film
 	if err := c.ShouldBindJSON(&film); err != nil {
 		log.Println(err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err := postgresql.DelFilm(film.Id)
+	err := h.films.DelFilm(c.Request.Context(), film.Id)
 	if err != nil {
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -368,8 +486,9 @@ func DeleteFilm(c *gin.Context) {
 // @Summary GetSortedFilms
 // @Security ApiKeyAuth
 // @Tags User Functions
-// @Description Get films sorted by rating, name, or date.
+// @Description Get the first page of films sorted by rating, name, or date. Use GET /filmlibrary/films instead.
 // @ID get-sorted-films
+// @Deprecated
 // @Accept json
 // @Produce json
 // @Param input body st.KeySort true "key: rating, name, or date to be sorted by"
@@ -378,44 +497,17 @@ func DeleteFilm(c *gin.Context) {
 // @Failure 400 {object} st.StatusBadRequestMessage "bad request"
 // @Failure 404 {object} st.StatusNotFoundMessage "not found"
 // @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
 // @Router /filmlibrary/filmssorted [post]
-func GetSortedFilms(c *gin.Context) {
-	if _, ok := c.Get("isAuthorizedUser"); !ok {
-		log.Println("Unauthorized user")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized user access denied"})
-		return
-	}
-	var films []st.Film
-	var sortKey st.KeySort
-	if err := c.ShouldBindJSON(&sortKey); err != nil {
-		log.Println(err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	if sortKey.Key != "key" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "wrong key"})
-		return
-	}
-	if sortKey.Value == "" || sortKey.Value == "rating" {
-		films = postgresql.GetFilmsSortedRating()
-	} else if sortKey.Value == "name" {
-		films = postgresql.GetFilmsSortedName()
-	} else {
-		films = postgresql.GetFilmsSortedDate()
-	}
-	if films == nil {
-		log.Println("No films found")
-		c.JSON(http.StatusNotFound, gin.H{"error": "No films found"})
-		return
-	}
-	c.JSON(http.StatusOK, films)
+func (h *Handler) GetSortedFilms(c *gin.Context) {
+	h.sortedFilms(c)
 }
 
 // GetFilmByPiece godoc
 // @Summary GetFilmByPiece
 // @Security ApiKeyAuth
 // @Tags User Functions
-// @Description Get films based on a JSON fragment, which contains a piece of the film name or actor name.
+// @Description Get films based on a JSON fragment, which contains a piece of the film name or actor full name and an optional genre. Matching ignores case.
 // @ID get-film-by-piece
 // @Accept json
 // @Produce json
@@ -425,71 +517,40 @@ func GetSortedFilms(c *gin.Context) {
 // @Failure 400 {object} st.StatusBadRequestMessage "bad request"
 // @Failure 404 {object} st.StatusNotFoundMessage "not found"
 // @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
 // @Router /filmlibrary/filmspiece [post]
-func GetFilmByPiece(c *gin.Context) {
-	if _, ok := c.Get("isAuthorizedUser"); !ok {
-		log.Println("Unauthorized user")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized user access denied"})
-		return
-	}
-	var JSONInput st.JSONFragment
-	var films []st.Film
-	var buf bytes.Buffer
-	_, err := buf.ReadFrom(c.Request.Body)
-	if err != nil {
-		log.Println(err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	if err = json.Unmarshal(buf.Bytes(), &JSONInput); err != nil {
-		log.Println(err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	if JSONInput.Key == "actor" {
-		films = postgresql.GetFilmsPieceActor(JSONInput.Fragment)
-	} else {
-		films = postgresql.GetFilmsPieceFilm(JSONInput.Fragment)
-	}
-	if films == nil {
-		log.Println("No films found")
-		c.JSON(http.StatusNotFound, gin.H{"error": "No films found"})
-		return
-	}
-	c.JSON(http.StatusOK, films)
+func (h *Handler) GetFilmByPiece(c *gin.Context) {
+	h.filmsByPiece(c)
 }
 
 // GetAllActors godoc
 // @Summary GetAllActors
 // @Security ApiKeyAuth
 // @Tags User Functions
-// @Description Get all actors
+// @Description Get a page of actors ordered by surname and name along with their filmography.
 // @ID get-all-actors
-// @Accept json
 // @Produce json
-// @Success 200 {object} st.StatusOKMessage "ok"
+// @Param name query string false "piece of the actor full name, case insensitive"
+// @Param limit query int false "page size, 20 by default, at most 100"
+// @Param offset query int false "number of actors to skip"
+// @Success 200 {object} st.ActorList "ok"
 // @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
 // @Failure 400 {object} st.StatusBadRequestMessage "bad request"
 // @Failure 404 {object} st.StatusNotFoundMessage "not found"
 // @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
 // @Router /filmlibrary/actors [get]
-func GetAllActors(c *gin.Context) {
-	if _, ok := c.Get("isAuthorizedUser"); !ok {
-		log.Println("Unauthorized user")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized user access denied"})
-		return
-	}
-	actors := postgresql.GetFilmsActor()
-	c.JSON(http.StatusOK, actors)
+func (h *Handler) GetAllActors(c *gin.Context) {
+	h.getAllActors(c)
 }
 
 // GetSortedFilmsAdmin godoc
 // @Summary GetSortedFilmsAdmin
 // @Security AdminKeyAuth
 // @Tags Admin Functions
-// @Description Get films sorted by rating, name, or date.
+// @Description Get the first page of films sorted by rating, name, or date. Use GET /filmlibrary/films instead.
 // @ID get-sorted-films-admin
+// @Deprecated
 // @Accept json
 // @Produce json
 // @Param input body st.KeySort true "key: rating, name, or date to be sorted by"
@@ -498,14 +559,74 @@ func GetAllActors(c *gin.Context) {
 // @Failure 400 {object} st.StatusBadRequestMessage "bad request"
 // @Failure 404 {object} st.StatusNotFoundMessage "not found"
 // @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
 // @Router /filmlibrary/admin/filmssorted [post]
-func GetSortedFilmsAdmin(c *gin.Context) {
-	if _, ok := c.Get("isAuthorizedAdmin"); !ok {
-		log.Println("Unauthorized admin")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized admin access denied"})
+func (h *Handler) GetSortedFilmsAdmin(c *gin.Context) {
+	h.sortedFilms(c)
+}
+
+// GetFilmByPieceAdmin godoc
+// @Summary GetFilmByPieceAdmin
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Get films based on a JSON fragment, which contains a piece of the film name or actor full name and an optional genre. Matching ignores case.
+// @ID get-film-by-piece-admin
+// @Accept json
+// @Produce json
+// @Param input body st.JSONFragment true "JSON fragment containing a piece of the film name or actor name to be searched for"
+// @Success 200 {object} st.StatusOKMessage "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 404 {object} st.StatusNotFoundMessage "not found"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/filmspiece [post]
+func (h *Handler) GetFilmByPieceAdmin(c *gin.Context) {
+	h.filmsByPiece(c)
+}
+
+// GetAllActorsAdmin godoc
+// @Summary GetAllActors
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Get a page of actors ordered by surname and name along with their filmography.
+// @ID get-all-actors-admin
+// @Produce json
+// @Param name query string false "piece of the actor full name, case insensitive"
+// @Param limit query int false "page size, 20 by default, at most 100"
+// @Param offset query int false "number of actors to skip"
+// @Success 200 {object} st.ActorList "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 404 {object} st.StatusNotFoundMessage "not found"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/actors [get]
+func (h *Handler) GetAllActorsAdmin(c *gin.Context) {
+	h.getAllActors(c)
+}
+
+func (h *Handler) getAllActors(c *gin.Context) {
+	var query st.ActorListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	var films []st.Film
+	actors, err := h.actors.GetFilmsActor(c.Request.Context(), query)
+	if errors.Is(err, repository.ErrInvalidQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, actors)
+}
+
+func (h *Handler) sortedFilms(c *gin.Context) {
 	var sortKey st.KeySort
 	if err := c.ShouldBindJSON(&sortKey); err != nil {
 		log.Println(err)
@@ -516,12 +637,11 @@ func GetSortedFilmsAdmin(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "wrong key"})
 		return
 	}
-	if sortKey.Value == "" || sortKey.Value == "rating" {
-		films = postgresql.GetFilmsSortedRating()
-	} else if sortKey.Value == "name" {
-		films = postgresql.GetFilmsSortedName()
-	} else {
-		films = postgresql.GetFilmsSortedDate()
+	films, err := h.legacySortedFilms(c.Request.Context(), sortKey)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 	if films == nil {
 		log.Println("No films found")
@@ -531,27 +651,7 @@ func GetSortedFilmsAdmin(c *gin.Context) {
 	c.JSON(http.StatusOK, films)
 }
 
-// GetFilmByPieceAdmin godoc
-// @Summary GetFilmByPieceAdmin
-// @Security AdminKeyAuth
-// @Tags Admin Functions
-// @Description Get films based on a JSON fragment, which contains a piece of the film name or actor name.
-// @ID get-film-by-piece-admin
-// @Accept json
-// @Produce json
-// @Param input body st.JSONFragment true "JSON fragment containing a piece of the film name or actor name to be searched for"
-// @Success 200 {object} st.StatusOKMessage "ok"
-// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
-// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
-// @Failure 404 {object} st.StatusNotFoundMessage "not found"
-// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
-// @Router /filmlibrary/admin/filmspiece [post]
-func GetFilmByPieceAdmin(c *gin.Context) {
-	if _, ok := c.Get("isAuthorizedAdmin"); !ok {
-		log.Println("Unauthorized admin")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized admin access denied"})
-		return
-	}
+func (h *Handler) filmsByPiece(c *gin.Context) {
 	var JSONInput st.JSONFragment
 	var films []st.Film
 	var buf bytes.Buffer
@@ -568,9 +668,14 @@ func GetFilmByPieceAdmin(c *gin.Context) {
 	}
 
 	if JSONInput.Key == "actor" {
-		films = postgresql.GetFilmsPieceActor(JSONInput.Fragment)
+		films, err = h.films.GetFilmsPieceActor(c.Request.Context(), JSONInput.Fragment, JSONInput.Genre)
 	} else {
-		films = postgresql.GetFilmsPieceFilm(JSONInput.Fragment)
+		films, err = h.films.GetFilmsPieceFilm(c.Request.Context(), JSONInput.Fragment, JSONInput.Genre)
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 	if films == nil {
 		log.Println("No films found")
@@ -579,27 +684,3 @@ func GetFilmByPieceAdmin(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, films)
 }
-
-// GetAllActorsAdmin godoc
-// @Summary GetAllActors
-// @Security AdminKeyAuth
-// @Tags Admin Functions
-// @Description Get all actors
-// @ID get-all-actors-admin
-// @Accept json
-// @Produce json
-// @Success 200 {object} st.StatusOKMessage "ok"
-// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
-// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
-// @Failure 404 {object} st.StatusNotFoundMessage "not found"
-// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
-// @Router /filmlibrary/admin/actors [get]
-func GetAllActorsAdmin(c *gin.Context) {
-	if _, ok := c.Get("isAuthorizedAdmin"); !ok {
-		log.Println("Unauthorized admin")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized admin access denied"})
-		return
-	}
-	actors := postgresql.GetFilmsActor()
-	c.JSON(http.StatusOK, actors)
-}
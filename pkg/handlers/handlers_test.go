@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	st "VK_app/internal/structures"
+	"VK_app/pkg/auth"
+	"VK_app/pkg/inmemory"
+	"VK_app/pkg/notify"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// newTestHandler returns a Handler on an empty in-memory store along with the store.
+func newTestHandler(t *testing.T) (*Handler, *inmemory.Storage) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	keys, err := auth.LoadKeySet([]auth.KeyConfig{{ID: "test", Algorithm: auth.HS256, Secret: testSecret}}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := inmemory.New()
+	h := New(Repositories{
+		Films:           storage,
+		Actors:          storage,
+		Genres:          storage,
+		Reviews:         storage,
+		Users:           storage,
+		Roles:           storage,
+		Sessions:        storage,
+		Resets:          storage,
+		Watchlists:      storage,
+		Collections:     storage,
+		Recommendations: storage,
+	}, keys, auth.Lifetimes{Access: 15 * time.Minute, Refresh: 720 * time.Hour, Reset: 30 * time.Minute}, notify.LogNotifier{})
+	return h, storage
+}
+
+// newTestRouter returns the film, actor and user routes served by a Handler on an empty in-memory store.
+// The routes are not behind the auth middleware, its checks are tested in pkg/middleware.
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	h, _ := newTestHandler(t)
+	router := gin.New()
+	router.POST("/registration", h.RegisterUser)
+	router.POST("/login", h.Login)
+	router.POST("/films", h.PostFilm)
+	router.GET("/films", h.ListFilms)
+	router.PUT("/film", h.UpdateFilm)
+	router.DELETE("/film", h.DeleteFilm)
+	router.GET("/films/:id", h.GetFilm)
+	router.POST("/actors", h.PostActor)
+	router.PUT("/actor", h.UpdateActor)
+	router.DELETE("/actor", h.DeleteActor)
+	router.GET("/actors/:id", h.GetActor)
+	router.POST("/actorsfilms", h.PostActorFilm)
+	router.PUT("/actorsfilms", h.UpdateActorFilm)
+	router.DELETE("/actorsfilms", h.DeleteActorFilm)
+	router.GET("/actorsfilms", h.GetFilmCredits)
+	router.GET("/search/films", h.SearchFilms)
+	router.GET("/genres", h.GetGenres)
+	router.POST("/genres", h.PostGenre)
+	router.PUT("/genre", h.UpdateGenre)
+	router.GET("/reviews", h.GetFilmReviews)
+	return router
+}
+
+// do sends the request with body encoded as JSON and decodes the response into out unless it is nil.
+func do(t *testing.T, router http.Handler, method, path string, body, out interface{}) int {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: decoding %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func expectStatus(t *testing.T, what string, got, want int) {
+	t.Helper()
+	if got != want {
+		t.Fatalf("%s: status %d, want %d", what, got, want)
+	}
+}
+
+func TestFilmFlow(t *testing.T) {
+	router := newTestRouter(t)
+	film := st.Film{Name: "Затмение", Description: "Описание фильма", Date: "20161125", Rating: 9}
+	expectStatus(t, "post film", do(t, router, http.MethodPost, "/films", film, nil), http.StatusCreated)
+
+	var details st.FilmDetails
+	expectStatus(t, "get film", do(t, router, http.MethodGet, "/films/1", nil, &details), http.StatusOK)
+	if details.Name != film.Name || details.Date != film.Date {
+		t.Fatalf("stored film is %+v", details.Film)
+	}
+	if details.Rating != 0 || details.Votes != 0 {
+		t.Fatalf("the rating must be computed from reviews, got %v of %d votes", details.Rating, details.Votes)
+	}
+	if details.Cast == nil || len(details.Cast) != 0 {
+		t.Fatalf("cast of a new film is %v, want an empty list", details.Cast)
+	}
+
+	update := st.Film{Id: 1, Description: "Новое описание"}
+	expectStatus(t, "update film", do(t, router, http.MethodPut, "/film", update, nil), http.StatusOK)
+	details = st.FilmDetails{}
+	do(t, router, http.MethodGet, "/films/1", nil, &details)
+	if details.Name != film.Name || details.Description != update.Description {
+		t.Fatalf("updated film is %+v, only the description must change", details.Film)
+	}
+
+	expectStatus(t, "delete film", do(t, router, http.MethodDelete, "/film", st.Film{Id: 1}, nil), http.StatusOK)
+	expectStatus(t, "get deleted film", do(t, router, http.MethodGet, "/films/1", nil, nil), http.StatusNotFound)
+	expectStatus(t, "get film by a wrong id", do(t, router, http.MethodGet, "/films/x", nil, nil), http.StatusBadRequest)
+}
+
+// TestEmptyListsAreArrays checks that an empty list is sent as [] rather than null.
+func TestEmptyListsAreArrays(t *testing.T) {
+	router := newTestRouter(t)
+	do(t, router, http.MethodPost, "/films", st.Film{Name: "Затмение", Date: "20161125"}, nil)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/films?date_from=20200101", `"films":[]`},
+		{"/films?offset=10", `"films":[]`},
+		{"/genres", `[]`},
+		{"/actorsfilms?film_id=1", `[]`},
+		{"/reviews?film_id=1", `"reviews":[]`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		expectStatus(t, tt.path, rec.Code, http.StatusOK)
+		if !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("GET %s answered %s, want %s", tt.path, rec.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestUpdateGenre(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, "post genre", do(t, router, http.MethodPost, "/genres", st.Genre{Name: "драма"}, nil), http.StatusCreated)
+	expectStatus(t, "post genre", do(t, router, http.MethodPost, "/genres", st.Genre{Name: "комедия"}, nil), http.StatusCreated)
+
+	tests := []struct {
+		name  string
+		genre st.Genre
+		want  int
+	}{
+		{"empty name", st.Genre{Id: 1}, http.StatusBadRequest},
+		{"unknown genre", st.Genre{Id: 42, Name: "мюзикл"}, http.StatusNotFound},
+		{"taken name", st.Genre{Id: 1, Name: "комедия"}, http.StatusConflict},
+		{"rename", st.Genre{Id: 1, Name: "мелодрама"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		expectStatus(t, tt.name, do(t, router, http.MethodPut, "/genre", tt.genre, nil), tt.want)
+	}
+	var genres []st.Genre
+	do(t, router, http.MethodGet, "/genres", nil, &genres)
+	if len(genres) != 2 || genres[1].Name != "мелодрама" {
+		t.Fatalf("genres are %+v", genres)
+	}
+}
+
+func TestActorFlow(t *testing.T) {
+	router := newTestRouter(t)
+	actor := st.Actor{Name: "Сергей", Surname: "Бурунов", FatherName: "Александрович", BirthDate: "19770306", Sex: "m"}
+	expectStatus(t, "post actor", do(t, router, http.MethodPost, "/actors", actor, nil), http.StatusCreated)
+
+	var stored st.ActorResponse
+	expectStatus(t, "get actor", do(t, router, http.MethodGet, "/actors/1", nil, &stored), http.StatusOK)
+	if stored.Id != 1 || stored.Surname != actor.Surname || stored.FatherName != actor.FatherName {
+		t.Fatalf("stored actor is %+v", stored)
+	}
+
+	expectStatus(t, "update actor", do(t, router, http.MethodPut, "/actor", st.Actor{Id: 1, Name: "Сергей Александрович"}, nil), http.StatusOK)
+	stored = st.ActorResponse{}
+	do(t, router, http.MethodGet, "/actors/1", nil, &stored)
+	if stored.Name != "Сергей Александрович" || stored.Surname != actor.Surname {
+		t.Fatalf("updated actor is %+v, only the name must change", stored)
+	}
+
+	expectStatus(t, "delete actor", do(t, router, http.MethodDelete, "/actor", st.Actor{Id: 1}, nil), http.StatusOK)
+	expectStatus(t, "get deleted actor", do(t, router, http.MethodGet, "/actors/1", nil, nil), http.StatusNotFound)
+}
+
+func TestCredits(t *testing.T) {
+	router := newTestRouter(t)
+	do(t, router, http.MethodPost, "/films", st.Film{Name: "Затмение", Date: "20161125"}, nil)
+	do(t, router, http.MethodPost, "/actors", st.Actor{Name: "Сергей", Surname: "Бурунов", BirthDate: "19770306", Sex: "m"}, nil)
+
+	tests := []struct {
+		name   string
+		credit st.ActorFilm
+		want   int
+	}{
+		{"unknown actor", st.ActorFilm{ActorID: 2, FilmID: 1}, http.StatusNotFound},
+		{"unknown film", st.ActorFilm{ActorID: 1, FilmID: 2}, http.StatusNotFound},
+		{"unknown credit type", st.ActorFilm{ActorID: 1, FilmID: 1, CreditType: "stuntman"}, http.StatusBadRequest},
+		{"actor", st.ActorFilm{ActorID: 1, FilmID: 1, Character: "Гриша", Billing: 1}, http.StatusCreated},
+		{"same credit again", st.ActorFilm{ActorID: 1, FilmID: 1, CreditType: "actor"}, http.StatusConflict},
+		{"another credit type", st.ActorFilm{ActorID: 1, FilmID: 1, CreditType: "director"}, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectStatus(t, "post credit", do(t, router, http.MethodPost, "/actorsfilms", tt.credit, nil), tt.want)
+		})
+	}
+
+	var credits []st.CreditResponse
+	expectStatus(t, "get credits", do(t, router, http.MethodGet, "/actorsfilms?film_id=1", nil, &credits), http.StatusOK)
+	if len(credits) != 2 || credits[0].CreditType != "actor" || credits[0].Character != "Гриша" || credits[1].CreditType != "director" {
+		t.Fatalf("credits are %+v", credits)
+	}
+	expectStatus(t, "update missing credit",
+		do(t, router, http.MethodPut, "/actorsfilms", st.ActorFilm{ActorID: 1, FilmID: 1, CreditType: "writer"}, nil), http.StatusNotFound)
+	expectStatus(t, "delete credit",
+		do(t, router, http.MethodDelete, "/actorsfilms", st.ActorFilm{ActorID: 1, FilmID: 1, CreditType: "director"}, nil), http.StatusOK)
+	expectStatus(t, "delete credit again",
+		do(t, router, http.MethodDelete, "/actorsfilms", st.ActorFilm{ActorID: 1, FilmID: 1, CreditType: "director"}, nil), http.StatusNotFound)
+}
+
+// newCastRouter returns a router with two films and two actors, the first actor plays in
+// both films and the second one only in the first film.
+func newCastRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	router := newTestRouter(t)
+	do(t, router, http.MethodPost, "/films", st.Film{Name: "Затмение", Date: "20161125"}, nil)
+	do(t, router, http.MethodPost, "/films", st.Film{Name: "Холоп", Date: "20191226"}, nil)
+	do(t, router, http.MethodPost, "/actors", st.Actor{Name: "Сергей", Surname: "Бурунов", BirthDate: "19770306", Sex: "m"}, nil)
+	do(t, router, http.MethodPost, "/actors", st.Actor{Name: "Милош", Surname: "Бикович", BirthDate: "19880213", Sex: "m"}, nil)
+	for _, credit := range []st.ActorFilm{{ActorID: 1, FilmID: 1}, {ActorID: 1, FilmID: 2}, {ActorID: 2, FilmID: 1}} {
+		expectStatus(t, "post credit", do(t, router, http.MethodPost, "/actorsfilms", credit, nil), http.StatusCreated)
+	}
+	return router
+}
+
+func TestDeletingFilmCascadesToCredits(t *testing.T) {
+	router := newCastRouter(t)
+	expectStatus(t, "delete film", do(t, router, http.MethodDelete, "/film", st.Film{Id: 1}, nil), http.StatusOK)
+
+	var actor st.ActorResponse
+	expectStatus(t, "get actor", do(t, router, http.MethodGet, "/actors/2", nil, &actor), http.StatusOK)
+	if len(actor.Films) != 0 {
+		t.Fatalf("films of the actor are %+v after the film was deleted", actor.Films)
+	}
+	actor = st.ActorResponse{}
+	do(t, router, http.MethodGet, "/actors/1", nil, &actor)
+	if len(actor.Films) != 1 || actor.Films[0].Id != 2 {
+		t.Fatalf("films of the actor are %+v, want only film 2", actor.Films)
+	}
+	expectStatus(t, "get credits of the deleted film", do(t, router, http.MethodGet, "/actorsfilms?film_id=1", nil, nil), http.StatusNotFound)
+}
+
+func TestDeletingActorCascadesToCredits(t *testing.T) {
+	router := newCastRouter(t)
+	expectStatus(t, "delete actor", do(t, router, http.MethodDelete, "/actor", st.Actor{Id: 1}, nil), http.StatusOK)
+
+	var credits []st.CreditResponse
+	expectStatus(t, "get credits", do(t, router, http.MethodGet, "/actorsfilms?film_id=2", nil, &credits), http.StatusOK)
+	if len(credits) != 0 {
+		t.Fatalf("credits of film 2 are %+v after its only actor was deleted", credits)
+	}
+	var details st.FilmDetails
+	expectStatus(t, "get film", do(t, router, http.MethodGet, "/films/1", nil, &details), http.StatusOK)
+	if len(details.Cast) != 1 || details.Cast[0].ActorID != 2 {
+		t.Fatalf("cast of film 1 is %+v, want only actor 2", details.Cast)
+	}
+}
+
+func TestUserFlow(t *testing.T) {
+	router := newTestRouter(t)
+	user := st.User{Login: "alice_smith", Password: "Film-lover-2024"}
+
+	tests := []struct {
+		name  string
+		path  string
+		user  st.User
+		want  int
+		error string
+	}{
+		{"weak password", "/registration", st.User{Login: "bob", Password: "short"}, http.StatusBadRequest, ""},
+		{"password containing the login", "/registration", st.User{Login: "cinema", Password: "my-cinema-2024"}, http.StatusBadRequest, ""},
+		{"register", "/registration", user, http.StatusCreated, ""},
+		{"same login again", "/registration", st.User{Login: user.Login, Password: "Another-pass-2024"}, http.StatusConflict, "User already exists"},
+		{"login", "/login", user, http.StatusOK, ""},
+		{"wrong password", "/login", st.User{Login: user.Login, Password: "Another-pass-2024"}, http.StatusUnauthorized, "Login or password is wrong"},
+		{"unknown login", "/login", st.User{Login: "nobody", Password: user.Password}, http.StatusUnauthorized, "Login or password is wrong"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]interface{}
+			expectStatus(t, tt.name, do(t, router, http.MethodPost, tt.path, tt.user, &body), tt.want)
+			if tt.error != "" && body["error"] != tt.error {
+				t.Fatalf("error is %v, want %q", body["error"], tt.error)
+			}
+		})
+	}
+
+	// the duplicate registration must not have replaced the password
+	var tokens st.TokenResponse
+	expectStatus(t, "login after the conflict", do(t, router, http.MethodPost, "/login", user, &tokens), http.StatusOK)
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" || tokens.ExpiresIn != int((15*time.Minute).Seconds()) {
+		t.Fatalf("tokens are %+v", tokens)
+	}
+}
+
+// TestLoginThrottleIgnoresForwardedFor checks that a client rotating X-Forwarded-For is still
+// locked out by its address unless the connection comes from a trusted proxy.
+func TestLoginThrottleIgnoresForwardedFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		want    int
+	}{
+		{"no trusted proxies", nil, http.StatusTooManyRequests},
+		// httptest requests come from 192.0.2.1
+		{"behind a trusted proxy", []string{"192.0.2.1"}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestHandler(t)
+			// unknown logins are checked against the dummy hash, a cheap one keeps the many attempts fast
+			hash, err := bcrypt.GenerateFromPassword([]byte("dummy"), bcrypt.MinCost)
+			if err != nil {
+				t.Fatal(err)
+			}
+			h.dummyHash = string(hash)
+			router := gin.New()
+			if err := router.SetTrustedProxies(tt.proxies); err != nil {
+				t.Fatal(err)
+			}
+			router.POST("/login", h.Login)
+			login := func(attempt int) int {
+				body := fmt.Sprintf(`{"login":"user_%d","password":"Wrong-guess-2024"}`, attempt)
+				req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", attempt))
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+				return rec.Code
+			}
+			// every attempt is on another login, only the address can lock them out
+			for attempt := 1; attempt <= auth.IPFreeAttempts+1; attempt++ {
+				expectStatus(t, "failed login", login(attempt), http.StatusUnauthorized)
+			}
+			expectStatus(t, "login from a new forwarded address", login(auth.IPFreeAttempts+2), tt.want)
+		})
+	}
+}
+
+func TestSearchSnippetIsEscaped(t *testing.T) {
+	router := newTestRouter(t)
+	film := st.Film{Name: "Затмение", Description: `<img src=x onerror="alert(1)"> & затмение`, Date: "20161125"}
+	expectStatus(t, "post film", do(t, router, http.MethodPost, "/films", film, nil), http.StatusCreated)
+
+	var hits []st.FilmHit
+	expectStatus(t, "search", do(t, router, http.MethodGet, "/search/films?q=затмение", nil, &hits), http.StatusOK)
+	if len(hits) != 1 {
+		t.Fatalf("hits are %+v", hits)
+	}
+	want := `<b>Затмение</b>. &lt;img src=x onerror=&#34;alert(1)&#34;&gt; &amp; <b>затмение</b>`
+	if hits[0].Snippet != want {
+		t.Fatalf("snippet is %q, want %q", hits[0].Snippet, want)
+	}
+}
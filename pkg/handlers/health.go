@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	st "VK_app/internal/structures"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkTimeout bounds every readiness check, a hanging dependency counts as a failure.
+const checkTimeout = 2 * time.Second
+
+// Health states reported by the probes.
+const (
+	healthOK          = "ok"
+	healthUnavailable = "unavailable"
+)
+
+// Check is a readiness check of a dependency of the service, Run returns nil when it is ready.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Health serves the liveness and readiness probes. They need no token, so they report
+// nothing but the names and the states of the checks, the errors only go to the log.
+type Health struct {
+	checks []Check
+}
+
+// NewHealth returns the probes running the checks.
+func NewHealth(checks ...Check) *Health {
+	return &Health{checks: checks}
+}
+
+// Healthz godoc
+// @Summary Healthz
+// @Tags health
+// @Description Liveness probe: answers as long as the process serves requests.
+// @ID healthz
+// @Produce json
+// @Success 200 {object} st.Health "ok"
+// @Router /healthz [get]
+func (hl *Health) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, st.Health{Status: healthOK})
+}
+
+// Readyz godoc
+// @Summary Readyz
+// @Tags health
+// @Description Readiness probe: runs every check, the database connection, the schema migrations and the readability of the key and TLS files, and reports the state of each of them.
+// @ID readyz
+// @Produce json
+// @Success 200 {object} st.Health "ok"
+// @Failure 503 {object} st.Health "a check failed"
+// @Router /readyz [get]
+func (hl *Health) Readyz(c *gin.Context) {
+	health := st.Health{Status: healthOK, Checks: make(map[string]st.CheckResult, len(hl.checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range hl.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := runCheck(c.Request.Context(), check)
+			mu.Lock()
+			defer mu.Unlock()
+			health.Checks[check.Name] = result
+			if result.Status != healthOK {
+				health.Status = healthUnavailable
+			}
+		}(check)
+	}
+	wg.Wait()
+	c.Header("Cache-Control", "no-store")
+	if health.Status != healthOK {
+		c.JSON(http.StatusServiceUnavailable, health)
+		return
+	}
+	c.JSON(http.StatusOK, health)
+}
+
+// runCheck runs the check within checkTimeout and times it.
+func runCheck(ctx context.Context, check Check) st.CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	start := time.Now()
+	err := check.Run(ctx)
+	result := st.CheckResult{Status: healthOK, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		log.Printf("readiness check %s failed: %v\n", check.Name, err)
+		result.Status = healthUnavailable
+	}
+	return result
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	st "VK_app/internal/structures"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadyz(t *testing.T) {
+	ok := Check{Name: "database", Run: func(context.Context) error { return nil }}
+	failing := Check{Name: "migrations", Run: func(context.Context) error {
+		return errors.New(`pq: password authentication failed for user "postgres"`)
+	}}
+	hanging := Check{Name: "files", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	tests := []struct {
+		name   string
+		checks []Check
+		want   int
+		states map[string]string
+	}{
+		{"ready", []Check{ok}, http.StatusOK, map[string]string{"database": healthOK}},
+		{"failed check", []Check{ok, failing}, http.StatusServiceUnavailable,
+			map[string]string{"database": healthOK, "migrations": healthUnavailable}},
+		{"hanging check", []Check{hanging}, http.StatusServiceUnavailable, map[string]string{"files": healthUnavailable}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/readyz", NewHealth(tt.checks...).Readyz)
+
+			start := time.Now()
+			var health st.Health
+			expectStatus(t, "readyz", do(t, router, http.MethodGet, "/readyz", nil, &health), tt.want)
+			if waited := time.Since(start); waited > checkTimeout+time.Second {
+				t.Fatalf("readyz took %s", waited)
+			}
+			if len(health.Checks) != len(tt.states) {
+				t.Fatalf("checks are %+v, want %v", health.Checks, tt.states)
+			}
+			for name, state := range tt.states {
+				if health.Checks[name].Status != state {
+					t.Fatalf("check %s is %q, want %q", name, health.Checks[name].Status, state)
+				}
+			}
+		})
+	}
+
+	router := gin.New()
+	router.GET("/readyz", NewHealth(failing).Readyz)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if strings.Contains(rec.Body.String(), "password") {
+		t.Fatalf("readyz leaks the error of the check: %s", rec.Body.String())
+	}
+}
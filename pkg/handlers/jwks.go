@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS godoc
+// @Summary JWKS
+// @Tags auth
+// @Description Public keys verifying the access tokens of the library, in the JSON Web Key Set format. Keys are matched by the kid header of a token. HS256 keys are never published.
+// @ID jwks
+// @Produce json
+// @Success 200 {object} st.JWKS "ok"
+// @Router /.well-known/jwks.json [get]
+func (h *Handler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	st "VK_app/internal/structures"
+	"VK_app/pkg/auth"
+	"VK_app/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// upgradePasswordHash rehashes the password the user has just logged in with at the current cost.
+// Failures are only logged, the login goes on with the old hash.
+func (h *Handler) upgradePasswordHash(ctx context.Context, user st.User) {
+	hash, err := auth.HashPassword(user.Password)
+	if err == nil {
+		err = h.users.UpdatePassword(ctx, user.Login, hash)
+	}
+	if err != nil {
+		log.Printf("upgrading the password hash of %s: %v\n", user.Login, err)
+	}
+}
+
+// confirmPassword checks the password of the authenticated user before a sensitive change.
+// Wrong passwords count as failed logins, so a stolen access token does not allow guessing
+// the password faster than the login does. It responds and returns false unless the password matches.
+func (h *Handler) confirmPassword(c *gin.Context, login, password, wrongMessage string) bool {
+	ip := c.ClientIP()
+	if wait := h.guard.Wait(login, ip); wait > 0 {
+		tooManyAttempts(c, wait)
+		return false
+	}
+	user, err := h.users.GetUser(c.Request.Context(), login)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return false
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		log.Printf("wrong password of %s from %s\n", login, ip)
+		h.guard.Fail(login, ip)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": wrongMessage})
+		return false
+	}
+	h.guard.Succeed(login)
+	return true
+}
+
+// ChangePassword godoc
+// @Summary ChangePassword
+// @Security ApiKeyAuth
+// @Tags auth
+// @Description Change the password of the current user. Every other session of the user is ended. Wrong old passwords count as failed logins and lock the account out the same way.
+// @ID change-password
+// @Accept json
+// @Produce json
+// @Param input body st.PasswordChange true "old and new passwords"
+// @Success 200 {object} st.StatusOKMessage "password was successfully changed"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request or weak password"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized or wrong old password"
+// @Failure 429 {object} st.StatusTooManyRequestsMessage "too many failed attempts, see Retry-After"
+// @Router /filmlibrary/password [put]
+func (h *Handler) ChangePassword(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	var change st.PasswordChange
+	if err := c.ShouldBindJSON(&change); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Wrong data format or missing data"})
+		return
+	}
+	if !h.confirmPassword(c, principal.Login, change.OldPassword, "Old password is wrong") {
+		return
+	}
+	if err := auth.ValidatePassword(change.NewPassword, principal.Login); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	hash, err := auth.HashPassword(change.NewPassword)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	if err := h.users.UpdatePassword(c.Request.Context(), principal.Login, hash); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	if err := h.sessions.RevokeUserSessions(c.Request.Context(), principal.Login, principal.SessionID); err != nil {
+		log.Println(err)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "updated"})
+}
+
+// RequestPasswordReset godoc
+// @Summary RequestPasswordReset
+// @Tags auth
+// @Description Send a single-use password reset token, valid for 30 minutes by default, to the user. The answer is the same whether the login exists or not.
+// @ID request-password-reset
+// @Accept json
+// @Produce json
+// @Param input body st.UserLogin true "login of the user"
+// @Success 202 {object} st.StatusOKMessage "reset token was sent if the user exists"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Router /filmlibrary/password/reset/request [post]
+func (h *Handler) RequestPasswordReset(c *gin.Context) {
+	var user st.UserLogin
+	if err := c.ShouldBindJSON(&user); err != nil || user.Login == "" {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "login is required"})
+		return
+	}
+	accepted := gin.H{"message": "if the user exists, a reset token was sent"}
+	if h.resetRequests.Wait(user.Login) > 0 {
+		log.Printf("password reset requests of %s are throttled\n", user.Login)
+		c.JSON(http.StatusAccepted, accepted)
+		return
+	}
+	h.resetRequests.Fail(user.Login)
+	token, hash, err := auth.NewResetToken()
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	expiresAt := time.Now().Add(h.lifetimes.Reset)
+	err = h.resets.CreatePasswordReset(c.Request.Context(), hash, user.Login, expiresAt)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusAccepted, accepted)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	if err := h.notifier.SendPasswordReset(user.Login, token, expiresAt); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	c.JSON(http.StatusAccepted, accepted)
+}
+
+// ResetPassword godoc
+// @Summary ResetPassword
+// @Tags auth
+// @Description Set a new password with a reset token. The token works once, every session of the user is ended and a login lockout is lifted.
+// @ID reset-password
+// @Accept json
+// @Produce json
+// @Param input body st.PasswordReset true "reset token and new password"
+// @Success 200 {object} st.StatusOKMessage "password was successfully reset"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request, weak password or invalid token"
+// @Router /filmlibrary/password/reset [post]
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var reset st.PasswordReset
+	if err := c.ShouldBindJSON(&reset); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Wrong data format or missing data"})
+		return
+	}
+	tokenHash := auth.HashToken(reset.Token)
+	login, err := h.resets.GetPasswordResetLogin(c.Request.Context(), tokenHash)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reset token is invalid, expired or already used"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	if err := auth.ValidatePassword(reset.NewPassword, login); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	hash, err := auth.HashPassword(reset.NewPassword)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	// the token may have been used up concurrently since it was looked up
+	login, err = h.resets.ResetPassword(c.Request.Context(), tokenHash, hash)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reset token is invalid, expired or already used"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	if err := h.sessions.RevokeUserSessions(c.Request.Context(), login, ""); err != nil {
+		log.Println(err)
+	}
+	h.guard.Unlock(login)
+	h.resetRequests.Reset(login)
+	c.JSON(http.StatusOK, gin.H{"message": "updated"})
+}
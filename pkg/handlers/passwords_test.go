@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	st "VK_app/internal/structures"
+	"VK_app/pkg/auth"
+	"VK_app/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestResetPassword(t *testing.T) {
+	h, storage := newTestHandler(t)
+	router := gin.New()
+	router.POST("/registration", h.RegisterUser)
+	router.POST("/login", h.Login)
+	router.POST("/password/reset", h.ResetPassword)
+
+	user := st.User{Login: "alice_smith", Password: "Film-lover-2024"}
+	expectStatus(t, "register", do(t, router, http.MethodPost, "/registration", user, nil), http.StatusCreated)
+	if err := storage.CreatePasswordReset(context.Background(), auth.HashToken("reset-token"), user.Login, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		reset st.PasswordReset
+		want  int
+		error string
+	}{
+		{"unknown token", st.PasswordReset{Token: "other-token", NewPassword: "Brand-new-2024"}, http.StatusBadRequest, "Reset token is invalid"},
+		{"password containing the login", st.PasswordReset{Token: "reset-token", NewPassword: "Alice_Smith-2024"}, http.StatusBadRequest, "must not contain the login"},
+		{"weak password", st.PasswordReset{Token: "reset-token", NewPassword: "short"}, http.StatusBadRequest, "at least"},
+		{"reset", st.PasswordReset{Token: "reset-token", NewPassword: "Brand-new-2024"}, http.StatusOK, ""},
+		{"token used again", st.PasswordReset{Token: "reset-token", NewPassword: "Another-one-2024"}, http.StatusBadRequest, "Reset token is invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]string
+			expectStatus(t, tt.name, do(t, router, http.MethodPost, "/password/reset", tt.reset, &body), tt.want)
+			if !strings.Contains(body["error"], tt.error) {
+				t.Fatalf("error is %q, want it to contain %q", body["error"], tt.error)
+			}
+		})
+	}
+
+	expectStatus(t, "login with the old password", do(t, router, http.MethodPost, "/login", user, nil), http.StatusUnauthorized)
+	user.Password = "Brand-new-2024"
+	expectStatus(t, "login with the new password", do(t, router, http.MethodPost, "/login", user, nil), http.StatusOK)
+}
+
+func TestChangePasswordIsThrottledLikeLogin(t *testing.T) {
+	h, storage := newTestHandler(t)
+	router := gin.New()
+	router.POST("/registration", h.RegisterUser)
+	router.POST("/login", h.Login)
+	router.PUT("/password", middleware.NewAuthenticator(h.keys, storage).CheckToken, h.ChangePassword)
+
+	user := st.User{Login: "alice_smith", Password: "Film-lover-2024"}
+	expectStatus(t, "register", do(t, router, http.MethodPost, "/registration", user, nil), http.StatusCreated)
+	var tokens st.TokenResponse
+	expectStatus(t, "login", do(t, router, http.MethodPost, "/login", user, &tokens), http.StatusOK)
+
+	change := st.PasswordChange{OldPassword: "Wrong-guess-2024", NewPassword: "Brand-new-2024"}
+	changePassword := func() int {
+		data, err := json.Marshal(change)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPut, "/password", bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	// the failure beyond the free attempts locks the login out
+	for attempt := 1; attempt <= auth.LoginFreeAttempts+1; attempt++ {
+		expectStatus(t, "wrong old password", changePassword(), http.StatusUnauthorized)
+	}
+	expectStatus(t, "wrong old password during the lockout", changePassword(), http.StatusTooManyRequests)
+	// the lockout is shared with the login
+	expectStatus(t, "login during the lockout", do(t, router, http.MethodPost, "/login", user, nil), http.StatusTooManyRequests)
+	change.OldPassword = user.Password
+	expectStatus(t, "right old password during the lockout", changePassword(), http.StatusTooManyRequests)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	st "VK_app/internal/structures"
+	"VK_app/pkg/recommend"
+	"VK_app/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSimilarFilms godoc
+// @Summary GetSimilarFilms
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Get the films most similar to a film by the people credited in both and the shared genres, each with the reasons it was picked for.
+// @ID get-similar-films
+// @Produce json
+// @Param id path int true "film id"
+// @Param limit query int false "number of films, 20 by default, at most 100"
+// @Success 200 {object} st.RecommendationList "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 404 {object} st.StatusNotFoundMessage "not found"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/films/{id}/similar [get]
+func (h *Handler) GetSimilarFilms(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Wrong film id"})
+		return
+	}
+	limit, ok := recommendationLimit(c)
+	if !ok {
+		return
+	}
+	films, err := h.recommendations.GetRelatedFilms(c.Request.Context(), []int{id}, []float64{1}, recommend.CandidateFactor*limit)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, target := range films {
+		if target.Film.Id == id {
+			c.JSON(http.StatusOK, st.RecommendationList{Films: recommend.Similar(target, films, limit)})
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Film not found"})
+}
+
+// GetRecommendations godoc
+// @Summary GetRecommendations
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Get films the current user has neither rated nor watched, picked by their similarity to the films the user rated or watched, each with the reasons it was picked for. Users with no such films get the best rated ones.
+// @ID get-recommendations
+// @Produce json
+// @Param limit query int false "number of films, 20 by default, at most 100"
+// @Success 200 {object} st.RecommendationList "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/recommendations [get]
+func (h *Handler) GetRecommendations(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	limit, ok := recommendationLimit(c)
+	if !ok {
+		return
+	}
+	signals, err := h.recommendations.GetUserSignals(c.Request.Context(), principal.Login)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var recs []st.Recommendation
+	if len(signals) > 0 {
+		ids := make([]int, len(signals))
+		weights := make([]float64, len(signals))
+		for i, signal := range signals {
+			ids[i], weights[i] = signal.FilmID, recommend.SignalWeight(signal)
+		}
+		films, err := h.recommendations.GetRelatedFilms(c.Request.Context(), ids, weights, recommend.CandidateFactor*limit)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		recs = recommend.ForUser(signals, films, limit)
+	}
+	if len(recs) == 0 {
+		list, err := h.films.ListFilms(c.Request.Context(), st.FilmListQuery{
+			Sort:  []st.SortKey{{Field: "rating", Order: "desc"}},
+			Limit: repository.MaxLimit,
+		})
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		recs = recommend.Popular(list.Films, signals, limit)
+	}
+	c.JSON(http.StatusOK, st.RecommendationList{Films: recs})
+}
+
+// recommendationLimit reads the limit query parameter, answering 400 if it is wrong.
+func recommendationLimit(c *gin.Context) (int, bool) {
+	limit, _, err := pageParams(c)
+	if err == nil {
+		err = repository.NormalizePage(&limit, 0)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	return limit, true
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	st "VK_app/internal/structures"
+	"VK_app/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PutReview godoc
+// @Summary PutReview
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Rate a film from 1 to 10 with an optional text. Posting again replaces the previous review of the user.
+// @ID put-review
+// @Accept json
+// @Produce json
+// @Param input body st.Review true "film_id, score and text of the review"
+// @Success 200 {object} st.StatusOKMessage "review was successfully saved"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 404 {object} st.StatusNotFoundMessage "not found"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/reviews [put]
+func (h *Handler) PutReview(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	var review st.Review
+	if err := c.ShouldBindJSON(&review); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	review.Login = principal.Login
+	err := h.reviews.UpsertReview(c.Request.Context(), review)
+	if errors.Is(err, repository.ErrInvalidReview) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Film not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "saved"})
+}
+
+// DeleteReview godoc
+// @Summary DeleteReview
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Delete the review of the user for a film.
+// @ID delete-review
+// @Accept json
+// @Produce json
+// @Param input body st.Review true "film_id of the review"
+// @Success 200 {object} st.StatusOKMessage "review was successfully deleted"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 404 {object} st.StatusNotFoundMessage "not found"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/reviews [delete]
+func (h *Handler) DeleteReview(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	var review st.Review
+	if err := c.ShouldBindJSON(&review); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.reviews.DelUserReview(c.Request.Context(), review.FilmID, principal.Login)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// GetFilmReviews godoc
+// @Summary GetFilmReviews
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Get a page of the reviews of a film, the newest first.
+// @ID get-film-reviews
+// @Produce json
+// @Param film_id query int true "film id"
+// @Param limit query int false "page size, 20 by default, at most 100"
+// @Param offset query int false "number of reviews to skip"
+// @Success 200 {object} st.ReviewList "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 404 {object} st.StatusNotFoundMessage "not found"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/reviews [get]
+func (h *Handler) GetFilmReviews(c *gin.Context) {
+	h.getFilmReviews(c)
+}
+
+// GetFilmReviewsAdmin godoc
+// @Summary GetFilmReviewsAdmin
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Get a page of the reviews of a film, the newest first.
+// @ID get-film-reviews-admin
+// @Produce json
+// @Param film_id query int true "film id"
+// @Param limit query int false "page size, 20 by default, at most 100"
+// @Param offset query int false "number of reviews to skip"
+// @Success 200 {object} st.ReviewList "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 404 {object} st.StatusNotFoundMessage "not found"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/reviews [get]
+func (h *Handler) GetFilmReviewsAdmin(c *gin.Context) {
+	h.getFilmReviews(c)
+}
+
+func (h *Handler) getFilmReviews(c *gin.Context) {
+	filmID, err := strconv.Atoi(c.Query("film_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Wrong film id"})
+		return
+	}
+	limit, offset, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = h.films.CheckFilm(c.Request.Context(), filmID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Film not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	reviews, err := h.reviews.GetFilmReviews(c.Request.Context(), filmID, limit, offset)
+	if errors.Is(err, repository.ErrInvalidQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, reviews)
+}
+
+// DeleteReviewAdmin godoc
+// @Summary DeleteReviewAdmin
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Remove any review, the film rating is recomputed.
+// @ID delete-review-admin
+// @Accept json
+// @Produce json
+// @Param input body st.Review true "id of the review"
+// @Success 200 {object} st.StatusOKMessage "review was successfully deleted"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 404 {object} st.StatusNotFoundMessage "not found"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/review [delete]
+func (h *Handler) DeleteReviewAdmin(c *gin.Context) {
+	var review st.Review
+	if err := c.ShouldBindJSON(&review); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.reviews.DelReview(c.Request.Context(), review.Id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// pageParams reads the optional limit and offset query parameters.
+func pageParams(c *gin.Context) (limit, offset int, err error) {
+	if raw := c.Query("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil {
+			return 0, 0, errors.New("wrong limit")
+		}
+	}
+	if raw := c.Query("offset"); raw != "" {
+		if offset, err = strconv.Atoi(raw); err != nil {
+			return 0, 0, errors.New("wrong offset")
+		}
+	}
+	return limit, offset, nil
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	st "VK_app/internal/structures"
+	"VK_app/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRoles godoc
+// @Summary GetRoles
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Get all roles along with the permissions they grant.
+// @ID get-roles
+// @Produce json
+// @Success 200 {array} st.Role "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/roles [get]
+func (h *Handler) GetRoles(c *gin.Context) {
+	roles, err := h.roles.GetRoles(c.Request.Context())
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// SetUserRole godoc
+// @Summary SetUserRole
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Assign a role to a user, it takes effect on the next request of the user.
+// @ID set-user-role
+// @Accept json
+// @Produce json
+// @Param input body st.UserRole true "login of the user and the role to assign"
+// @Success 200 {object} st.StatusOKMessage "role was successfully assigned"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Failure 404 {object} st.StatusNotFoundMessage "user or role not found"
+// @Router /filmlibrary/admin/users/role [put]
+func (h *Handler) SetUserRole(c *gin.Context) {
+	var userRole st.UserRole
+	if err := c.ShouldBindJSON(&userRole); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if userRole.Login == "" || userRole.Role == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "login and role are required"})
+		return
+	}
+	err := h.roles.SetUserRole(c.Request.Context(), userRole.Login, userRole.Role)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User or role not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "updated"})
+}
+
+// UnlockUser godoc
+// @Summary UnlockUser
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Lift the lockout a login got after too many failed login attempts.
+// @ID unlock-user
+// @Accept json
+// @Produce json
+// @Param input body st.UserLogin true "login to unlock"
+// @Success 200 {object} st.StatusOKMessage "login was successfully unlocked"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/users/unlock [post]
+func (h *Handler) UnlockUser(c *gin.Context) {
+	var user st.UserLogin
+	if err := c.ShouldBindJSON(&user); err != nil || user.Login == "" {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "login is required"})
+		return
+	}
+	h.guard.Unlock(user.Login)
+	c.JSON(http.StatusOK, gin.H{"message": "unlocked"})
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	st "VK_app/internal/structures"
+	"VK_app/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchFilms godoc
+// @Summary SearchFilms
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Full-text search over film names, descriptions and cast names in Russian and English, tolerant to typos. The best matches come first, matched words are wrapped in <b></b> in the snippet, the rest of which is HTML-escaped.
+// @ID search-films
+// @Produce json
+// @Param q query string true "search text" example(Киану Ривс)
+// @Param limit query int false "page size, 20 by default, at most 100"
+// @Param offset query int false "number of hits to skip"
+// @Success 200 {array} st.FilmHit "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/search/films [get]
+func (h *Handler) SearchFilms(c *gin.Context) {
+	h.searchFilms(c)
+}
+
+// SearchFilmsAdmin godoc
+// @Summary SearchFilmsAdmin
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Full-text search over film names, descriptions and cast names in Russian and English, tolerant to typos. The best matches come first, matched words are wrapped in <b></b> in the snippet, the rest of which is HTML-escaped.
+// @ID search-films-admin
+// @Produce json
+// @Param q query string true "search text" example(Киану Ривс)
+// @Param limit query int false "page size, 20 by default, at most 100"
+// @Param offset query int false "number of hits to skip"
+// @Success 200 {array} st.FilmHit "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/search/films [get]
+func (h *Handler) SearchFilmsAdmin(c *gin.Context) {
+	h.searchFilms(c)
+}
+
+func (h *Handler) searchFilms(c *gin.Context) {
+	limit, offset, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	hits, err := h.films.SearchFilms(c.Request.Context(), c.Query("q"), limit, offset)
+	if errors.Is(err, repository.ErrInvalidQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, hits)
+}
+
+// SearchActors godoc
+// @Summary SearchActors
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Full-text search over actor full names, tolerant to typos. The best matches come first.
+// @ID search-actors
+// @Produce json
+// @Param q query string true "search text" example(Киану Ривс)
+// @Param limit query int false "page size, 20 by default, at most 100"
+// @Param offset query int false "number of hits to skip"
+// @Success 200 {array} st.ActorHit "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/search/actors [get]
+func (h *Handler) SearchActors(c *gin.Context) {
+	h.searchActors(c)
+}
+
+// SearchActorsAdmin godoc
+// @Summary SearchActorsAdmin
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Full-text search over actor full names, tolerant to typos. The best matches come first.
+// @ID search-actors-admin
+// @Produce json
+// @Param q query string true "search text" example(Киану Ривс)
+// @Param limit query int false "page size, 20 by default, at most 100"
+// @Param offset query int false "number of hits to skip"
+// @Success 200 {array} st.ActorHit "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/search/actors [get]
+func (h *Handler) SearchActorsAdmin(c *gin.Context) {
+	h.searchActors(c)
+}
+
+func (h *Handler) searchActors(c *gin.Context) {
+	limit, offset, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	hits, err := h.actors.SearchActors(c.Request.Context(), c.Query("q"), limit, offset)
+	if errors.Is(err, repository.ErrInvalidQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, hits)
+}
+
+const (
+	// defaultTypeaheadLimit and maxTypeaheadLimit bound the number of hits per group in the typeahead.
+	defaultTypeaheadLimit = 5
+	maxTypeaheadLimit     = 20
+)
+
+// Search godoc
+// @Summary Search
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Typeahead search returning matching actors and films grouped by type, the best matches first in each group.
+// @ID search
+// @Produce json
+// @Param q query string true "search text" example(Киану)
+// @Param limit query int false "hits per group, 5 by default, at most 20"
+// @Success 200 {object} st.SearchResult "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/search [get]
+func (h *Handler) Search(c *gin.Context) {
+	h.search(c)
+}
+
+// SearchAdmin godoc
+// @Summary SearchAdmin
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Typeahead search returning matching actors and films grouped by type, the best matches first in each group.
+// @ID search-admin
+// @Produce json
+// @Param q query string true "search text" example(Киану)
+// @Param limit query int false "hits per group, 5 by default, at most 20"
+// @Success 200 {object} st.SearchResult "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/search [get]
+func (h *Handler) SearchAdmin(c *gin.Context) {
+	h.search(c)
+}
+
+func (h *Handler) search(c *gin.Context) {
+	limit := defaultTypeaheadLimit
+	if raw := c.Query("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 || limit > maxTypeaheadLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 20"})
+			return
+		}
+	}
+	query := c.Query("q")
+	actors, err := h.actors.SearchActors(c.Request.Context(), query, limit, 0)
+	if err != nil {
+		searchFailed(c, err)
+		return
+	}
+	films, err := h.films.SearchFilms(c.Request.Context(), query, limit, 0)
+	if err != nil {
+		searchFailed(c, err)
+		return
+	}
+	result := st.SearchResult{Actors: []st.ActorHit{}, Films: []st.FilmHit{}}
+	result.Actors = append(result.Actors, actors...)
+	result.Films = append(result.Films, films...)
+	c.JSON(http.StatusOK, result)
+}
+
+// searchFailed reports a search error, invalid queries are the client's fault.
+func searchFailed(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrInvalidQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Println(err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	st "VK_app/internal/structures"
+	"VK_app/pkg/auth"
+	"VK_app/pkg/middleware"
+	"VK_app/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Refresh godoc
+// @Summary Refresh
+// @Tags auth
+// @Description Exchange a refresh token for a new access token and a new refresh token. Every refresh token works once, presenting a used one again revokes the whole session. Without a body the refresh token is read from its cookie, then the X-CSRF-Token header is required.
+// @ID refresh
+// @Accept json
+// @Produce json
+// @Param input body st.RefreshRequest false "refresh token"
+// @Success 200 {object} st.TokenResponse "tokens were successfully refreshed"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "invalid, expired or reused refresh token"
+// @Failure 403 {object} st.StatusForbiddenMessage "wrong CSRF token or account is disabled"
+// @Router /filmlibrary/refresh [post]
+func (h *Handler) Refresh(c *gin.Context) {
+	var request st.RefreshRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Wrong data format or missing data"})
+			return
+		}
+	}
+	cookieMode := false
+	if request.RefreshToken == "" {
+		cookie, err := c.Cookie(auth.RefreshCookie)
+		if err != nil || cookie == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Refresh token is missing"})
+			return
+		}
+		if !auth.CheckCSRF(c.Request) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "CSRF token is missing or wrong", "code": middleware.CodeCSRFFailed})
+			return
+		}
+		request.RefreshToken, cookieMode = cookie, true
+	}
+	refreshToken, refreshHash, err := auth.NewRefreshToken()
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	session, err := h.sessions.RotateRefreshToken(c.Request.Context(), auth.HashToken(request.RefreshToken),
+		st.RefreshToken{Hash: refreshHash, ExpiresAt: time.Now().Add(h.lifetimes.Refresh)})
+	if errors.Is(err, repository.ErrTokenReused) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token was already used, the session is revoked"})
+		return
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token is invalid or expired"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	user, err := h.users.GetUser(c.Request.Context(), session.Login)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	if user.Disabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+		return
+	}
+	h.respondTokens(c, user, session.Id, refreshToken, cookieMode, "tokens were refreshed successfully")
+}
+
+// respondTokens signs an access token for the session and sends it along with the refresh token,
+// either in the body and the Authorization header or, in cookie mode, in HttpOnly cookies.
+func (h *Handler) respondTokens(c *gin.Context, user st.User, sessionID, refreshToken string, cookieMode bool, message string) {
+	accessToken, err := h.keys.NewAccessToken(user.Login, user.Role, sessionID, time.Now(), h.lifetimes.Access)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	response := st.TokenResponse{Message: message, ExpiresIn: int(h.lifetimes.Access.Seconds())}
+	if !cookieMode {
+		response.AccessToken, response.TokenType, response.RefreshToken = accessToken, "Bearer", refreshToken
+		c.Header("Authorization", accessToken)
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	csrfToken, err := auth.NewCSRFToken()
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	setCookie(c, auth.AccessCookie, accessToken, "/filmlibrary", h.lifetimes.Access, true)
+	setCookie(c, auth.RefreshCookie, refreshToken, "/filmlibrary/refresh", h.lifetimes.Refresh, true)
+	setCookie(c, auth.CSRFCookie, csrfToken, "/", h.lifetimes.Refresh, false)
+	response.CSRFToken = csrfToken
+	c.JSON(http.StatusOK, response)
+}
+
+// setCookie sets a secure strict same-site cookie, a zero maxAge deletes it.
+func setCookie(c *gin.Context, name, value, path string, maxAge time.Duration, httpOnly bool) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   true,
+		HttpOnly: httpOnly,
+		SameSite: http.SameSiteStrictMode,
+	}
+	if maxAge == 0 {
+		cookie.MaxAge = -1
+	}
+	http.SetCookie(c.Writer, cookie)
+}
+
+// Logout godoc
+// @Summary Logout
+// @Security ApiKeyAuth
+// @Tags auth
+// @Description Revoke the current session, its access and refresh tokens stop being accepted.
+// @ID logout
+// @Produce json
+// @Success 200 {object} st.StatusOKMessage "user was successfully logged out"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Router /filmlibrary/logout [post]
+func (h *Handler) Logout(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	if err := h.sessions.RevokeSession(c.Request.Context(), principal.SessionID); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	clearAuthCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// clearAuthCookies deletes the cookies of the cookie mode.
+func clearAuthCookies(c *gin.Context) {
+	setCookie(c, auth.AccessCookie, "", "/filmlibrary", 0, true)
+	setCookie(c, auth.RefreshCookie, "", "/filmlibrary/refresh", 0, true)
+	setCookie(c, auth.CSRFCookie, "", "/", 0, false)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	st "VK_app/internal/structures"
+	"VK_app/pkg/auth"
+	"VK_app/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newSessionRouter returns the session routes along with GET /me behind the auth middleware,
+// alice_smith is registered.
+func newSessionRouter(t *testing.T) (*gin.Engine, st.User) {
+	t.Helper()
+	h, storage := newTestHandler(t)
+	router := gin.New()
+	router.POST("/registration", h.RegisterUser)
+	router.POST("/login", h.Login)
+	router.POST("/refresh", h.Refresh)
+	authenticated := router.Group("/", middleware.NewAuthenticator(h.keys, storage).CheckToken)
+	authenticated.POST("/logout", h.Logout)
+	authenticated.GET("/me", h.GetMe)
+
+	user := st.User{Login: "alice_smith", Password: "Film-lover-2024"}
+	expectStatus(t, "register", do(t, router, http.MethodPost, "/registration", user, nil), http.StatusCreated)
+	return router, user
+}
+
+// withToken sends the request with the access token and returns the status along with the body.
+func withToken(router http.Handler, method, path, token string) (int, map[string]string) {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	var body map[string]string
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	return rec.Code, body
+}
+
+// expectRevoked checks that the access token is refused because its session has ended.
+func expectRevoked(t *testing.T, router http.Handler, token string) {
+	t.Helper()
+	code, body := withToken(router, http.MethodGet, "/me", token)
+	if code != http.StatusUnauthorized || body["code"] != middleware.CodeSessionRevoked {
+		t.Fatalf("access token of an ended session got %d %v, want 401 %s", code, body, middleware.CodeSessionRevoked)
+	}
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	router, user := newSessionRouter(t)
+	var login st.TokenResponse
+	expectStatus(t, "login", do(t, router, http.MethodPost, "/login", user, &login), http.StatusOK)
+
+	var refreshed st.TokenResponse
+	expectStatus(t, "refresh", do(t, router, http.MethodPost, "/refresh", st.RefreshRequest{RefreshToken: login.RefreshToken}, &refreshed), http.StatusOK)
+	if refreshed.AccessToken == "" || refreshed.RefreshToken == "" || refreshed.RefreshToken == login.RefreshToken {
+		t.Fatalf("refresh returned %+v, want a new pair of tokens", refreshed)
+	}
+	if code, _ := withToken(router, http.MethodGet, "/me", refreshed.AccessToken); code != http.StatusOK {
+		t.Fatalf("refreshed access token got %d", code)
+	}
+
+	var next st.TokenResponse
+	expectStatus(t, "refresh with the new token", do(t, router, http.MethodPost, "/refresh", st.RefreshRequest{RefreshToken: refreshed.RefreshToken}, &next), http.StatusOK)
+	expectStatus(t, "unknown refresh token", do(t, router, http.MethodPost, "/refresh", st.RefreshRequest{RefreshToken: "unknown"}, nil), http.StatusUnauthorized)
+}
+
+func TestReusedRefreshTokenRevokesTheSession(t *testing.T) {
+	router, user := newSessionRouter(t)
+	var login st.TokenResponse
+	expectStatus(t, "login", do(t, router, http.MethodPost, "/login", user, &login), http.StatusOK)
+	var refreshed st.TokenResponse
+	expectStatus(t, "refresh", do(t, router, http.MethodPost, "/refresh", st.RefreshRequest{RefreshToken: login.RefreshToken}, &refreshed), http.StatusOK)
+
+	var body map[string]string
+	expectStatus(t, "replayed refresh token", do(t, router, http.MethodPost, "/refresh", st.RefreshRequest{RefreshToken: login.RefreshToken}, &body), http.StatusUnauthorized)
+	if !strings.Contains(body["error"], "revoked") {
+		t.Fatalf("replay answered %v, want the session revoked", body)
+	}
+	// the whole session ends, the tokens issued after the stolen one included
+	expectStatus(t, "refresh token issued before the replay", do(t, router, http.MethodPost, "/refresh", st.RefreshRequest{RefreshToken: refreshed.RefreshToken}, nil), http.StatusUnauthorized)
+	expectRevoked(t, router, refreshed.AccessToken)
+	expectRevoked(t, router, login.AccessToken)
+}
+
+func TestLogoutEndsTheSession(t *testing.T) {
+	router, user := newSessionRouter(t)
+	var login, other st.TokenResponse
+	expectStatus(t, "login", do(t, router, http.MethodPost, "/login", user, &login), http.StatusOK)
+	expectStatus(t, "login elsewhere", do(t, router, http.MethodPost, "/login", user, &other), http.StatusOK)
+
+	if code, _ := withToken(router, http.MethodPost, "/logout", login.AccessToken); code != http.StatusOK {
+		t.Fatalf("logout got %d", code)
+	}
+	expectRevoked(t, router, login.AccessToken)
+	expectStatus(t, "refresh after the logout", do(t, router, http.MethodPost, "/refresh", st.RefreshRequest{RefreshToken: login.RefreshToken}, nil), http.StatusUnauthorized)
+	// the other session of the user goes on
+	if code, _ := withToken(router, http.MethodGet, "/me", other.AccessToken); code != http.StatusOK {
+		t.Fatalf("access token of another session got %d after the logout", code)
+	}
+}
+
+func TestCookieRefreshNeedsCSRFToken(t *testing.T) {
+	router, user := newSessionRouter(t)
+	login := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/login?cookie=true", strings.NewReader(`{"login":"`+user.Login+`","password":"`+user.Password+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(login, req)
+	expectStatus(t, "cookie login", login.Code, http.StatusOK)
+	var tokens st.TokenResponse
+	if err := json.Unmarshal(login.Body.Bytes(), &tokens); err != nil {
+		t.Fatal(err)
+	}
+	if tokens.RefreshToken != "" || tokens.CSRFToken == "" {
+		t.Fatalf("cookie login answered %+v, want only the CSRF token in the body", tokens)
+	}
+
+	refresh := func(csrf string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/refresh", nil)
+		for _, cookie := range login.Result().Cookies() {
+			req.AddCookie(cookie)
+		}
+		if csrf != "" {
+			req.Header.Set(auth.CSRFHeader, csrf)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	rec := refresh("")
+	expectStatus(t, "refresh without the CSRF header", rec.Code, http.StatusForbidden)
+	if !strings.Contains(rec.Body.String(), middleware.CodeCSRFFailed) {
+		t.Fatalf("refresh without the CSRF header answered %s", rec.Body.String())
+	}
+	expectStatus(t, "refresh with a wrong CSRF header", refresh("wrong").Code, http.StatusForbidden)
+	expectStatus(t, "refresh with the CSRF header", refresh(tokens.CSRFToken).Code, http.StatusOK)
+}
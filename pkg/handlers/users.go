@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	st "VK_app/internal/structures"
+	"VK_app/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMe godoc
+// @Summary GetMe
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Get the profile of the current user.
+// @ID get-me
+// @Produce json
+// @Success 200 {object} st.Profile "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Router /filmlibrary/me [get]
+func (h *Handler) GetMe(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	profile, err := h.users.GetProfile(c.Request.Context(), principal.Login)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// UpdateMe godoc
+// @Summary UpdateMe
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Change the display name, the email or the preferred language (ru or en) of the current user. Omitted fields are kept, an empty display name or email clears it.
+// @ID update-me
+// @Accept json
+// @Produce json
+// @Param input body st.ProfileUpdate true "profile fields to change"
+// @Success 200 {object} st.StatusOKMessage "profile was successfully updated"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 409 {object} st.StatusBadRequestMessage "email is taken"
+// @Router /filmlibrary/me [patch]
+func (h *Handler) UpdateMe(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	var update st.ProfileUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.users.UpdateProfile(c.Request.Context(), principal.Login, update)
+	if errors.Is(err, repository.ErrInvalidProfile) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email is already used by another account"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "updated"})
+}
+
+// DeleteMe godoc
+// @Summary DeleteMe
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Delete the account of the current user along with the reviews, sessions and other data of the user. The password confirms the deletion.
+// @ID delete-me
+// @Accept json
+// @Produce json
+// @Param input body st.AccountDeletion true "password of the user"
+// @Success 200 {object} st.StatusOKMessage "account was successfully deleted"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized or wrong password"
+// @Failure 429 {object} st.StatusTooManyRequestsMessage "too many failed attempts, see Retry-After"
+// @Router /filmlibrary/me [delete]
+func (h *Handler) DeleteMe(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	var deletion st.AccountDeletion
+	if err := c.ShouldBindJSON(&deletion); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Wrong data format or missing data"})
+		return
+	}
+	if !h.confirmPassword(c, principal.Login, deletion.Password, "Password is wrong") {
+		return
+	}
+	if err := h.users.DelUser(c.Request.Context(), principal.Login); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	clearAuthCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// ListUsers godoc
+// @Summary ListUsers
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Get a page of users ordered by login. The query matches a piece of the login, display name or email, ignoring case.
+// @ID list-users
+// @Produce json
+// @Param query query string false "piece of the login, display name or email"
+// @Param disabled query bool false "only disabled or only enabled users"
+// @Param limit query int false "page size, 20 by default, at most 100"
+// @Param offset query int false "number of users to skip"
+// @Success 200 {object} st.UserList "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/admin/users [get]
+func (h *Handler) ListUsers(c *gin.Context) {
+	var query st.UserListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	users, err := h.users.ListUsers(c.Request.Context(), query)
+	if errors.Is(err, repository.ErrInvalidQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// DisableUser godoc
+// @Summary DisableUser
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Disable a user: every session of the user ends and logging in is refused until the user is enabled again.
+// @ID disable-user
+// @Accept json
+// @Produce json
+// @Param input body st.UserLogin true "login to disable"
+// @Success 200 {object} st.StatusOKMessage "user was successfully disabled"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Failure 404 {object} st.StatusNotFoundMessage "user not found"
+// @Router /filmlibrary/admin/users/disable [post]
+func (h *Handler) DisableUser(c *gin.Context) {
+	h.setUserDisabled(c, true)
+}
+
+// EnableUser godoc
+// @Summary EnableUser
+// @Security AdminKeyAuth
+// @Tags Admin Functions
+// @Description Enable a disabled user again.
+// @ID enable-user
+// @Accept json
+// @Produce json
+// @Param input body st.UserLogin true "login to enable"
+// @Success 200 {object} st.StatusOKMessage "user was successfully enabled"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Failure 404 {object} st.StatusNotFoundMessage "user not found"
+// @Router /filmlibrary/admin/users/enable [post]
+func (h *Handler) EnableUser(c *gin.Context) {
+	h.setUserDisabled(c, false)
+}
+
+func (h *Handler) setUserDisabled(c *gin.Context, disabled bool) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	var user st.UserLogin
+	if err := c.ShouldBindJSON(&user); err != nil || user.Login == "" {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "login is required"})
+		return
+	}
+	if disabled && user.Login == principal.Login {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Admins can not disable their own account"})
+		return
+	}
+	err := h.users.SetUserDisabled(c.Request.Context(), user.Login, disabled)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "updated"})
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	st "VK_app/internal/structures"
+	"VK_app/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetWatchlist godoc
+// @Summary GetWatchlist
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Get a page of the films the user plans to watch, the latest added first.
+// @ID get-watchlist
+// @Produce json
+// @Param limit query int false "page size, 20 by default, at most 100"
+// @Param offset query int false "number of films to skip"
+// @Success 200 {object} st.Watchlist "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/watchlist [get]
+func (h *Handler) GetWatchlist(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	limit, offset, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	list, err := h.watchlists.GetWatchlist(c.Request.Context(), principal.Login, limit, offset)
+	if errors.Is(err, repository.ErrInvalidQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// PostWatchlist godoc
+// @Summary PostWatchlist
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Put a film on the watchlist of the user.
+// @ID post-watchlist
+// @Accept json
+// @Produce json
+// @Param input body st.WatchlistFilm true "film id"
+// @Success 201 {object} st.StatusOKMessage "film was successfully added"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Failure 404 {object} st.StatusNotFoundMessage "film not found"
+// @Failure 409 {object} st.StatusBadRequestMessage "film is already on the watchlist"
+// @Router /filmlibrary/watchlist [post]
+func (h *Handler) PostWatchlist(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	var film st.WatchlistFilm
+	if err := c.ShouldBindJSON(&film); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.watchlists.AddToWatchlist(c.Request.Context(), principal.Login, film.FilmID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Film not found"})
+		return
+	}
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Film is already on the watchlist"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "created"})
+}
+
+// DeleteWatchlist godoc
+// @Summary DeleteWatchlist
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Take a film off the watchlist of the user.
+// @ID delete-watchlist
+// @Accept json
+// @Produce json
+// @Param input body st.WatchlistFilm true "film id"
+// @Success 200 {object} st.StatusOKMessage "film was successfully removed"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Failure 404 {object} st.StatusNotFoundMessage "film is not on the watchlist"
+// @Router /filmlibrary/watchlist [delete]
+func (h *Handler) DeleteWatchlist(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	var film st.WatchlistFilm
+	if err := c.ShouldBindJSON(&film); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.watchlists.RemoveFromWatchlist(c.Request.Context(), principal.Login, film.FilmID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Film is not on the watchlist"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// GetWatchHistory godoc
+// @Summary GetWatchHistory
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Get a page of the films the user watched, the latest viewing first.
+// @ID get-watch-history
+// @Produce json
+// @Param limit query int false "page size, 20 by default, at most 100"
+// @Param offset query int false "number of viewings to skip"
+// @Success 200 {object} st.WatchHistory "ok"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Router /filmlibrary/watched [get]
+func (h *Handler) GetWatchHistory(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	limit, offset, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	history, err := h.watchlists.GetWatchHistory(c.Request.Context(), principal.Login, limit, offset)
+	if errors.Is(err, repository.ErrInvalidQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// PostWatched godoc
+// @Summary PostWatched
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Mark a film as watched on a date, today if it is omitted. The film is taken off the watchlist.
+// @ID post-watched
+// @Accept json
+// @Produce json
+// @Param input body st.WatchedFilm true "film id and date as YYYYMMDD"
+// @Success 200 {object} st.StatusOKMessage "viewing was successfully saved"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Failure 404 {object} st.StatusNotFoundMessage "film not found"
+// @Router /filmlibrary/watched [post]
+func (h *Handler) PostWatched(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	var watched st.WatchedFilm
+	if err := c.ShouldBindJSON(&watched); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.watchlists.MarkWatched(c.Request.Context(), principal.Login, watched)
+	if errors.Is(err, repository.ErrInvalidWatch) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Film not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "saved"})
+}
+
+// DeleteWatched godoc
+// @Summary DeleteWatched
+// @Security ApiKeyAuth
+// @Tags User Functions
+// @Description Delete the viewing of a film on a date or, if the date is omitted, every viewing of the film.
+// @ID delete-watched
+// @Accept json
+// @Produce json
+// @Param input body st.WatchedFilm true "film id and optional date as YYYYMMDD"
+// @Success 200 {object} st.StatusOKMessage "viewing was successfully deleted"
+// @Failure 500 {object} st.StatusInternalServerErrorMessage "internal server error"
+// @Failure 400 {object} st.StatusBadRequestMessage "bad request"
+// @Failure 401 {object} st.StatusUnauthorizedMessage "unauthorized"
+// @Failure 403 {object} st.StatusForbiddenMessage "forbidden"
+// @Failure 404 {object} st.StatusNotFoundMessage "viewing not found"
+// @Router /filmlibrary/watched [delete]
+func (h *Handler) DeleteWatched(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+	var watched st.WatchedFilm
+	if err := c.ShouldBindJSON(&watched); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.watchlists.UnmarkWatched(c.Request.Context(), principal.Login, watched)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Viewing not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
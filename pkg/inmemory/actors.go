@@ -0,0 +1,87 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// GetActor returns the actor with the given id along with the credited films ordered by release date.
+func (s *Storage) GetActor(ctx context.Context, id int) (structures.ActorResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	actor, ok := s.actors[id]
+	if !ok {
+		return structures.ActorResponse{}, repository.ErrNotFound
+	}
+	return s.actorResponse(actor), nil
+}
+
+// actorResponse returns the actor along with the credited films. The caller must hold the lock.
+func (s *Storage) actorResponse(actor structures.Actor) structures.ActorResponse {
+	response := structures.ActorResponse{
+		Id:         actor.Id,
+		Name:       actor.Name,
+		Surname:    actor.Surname,
+		FatherName: actor.FatherName,
+		BirthDate:  actor.BirthDate,
+		Sex:        actor.Sex,
+		Films:      []structures.FilmResponse{},
+	}
+	for _, credit := range s.actorsFilms {
+		if credit.ActorID == actor.Id {
+			film := s.films[credit.FilmID]
+			response.Films = append(response.Films, structures.FilmResponse{
+				Id:         film.Id,
+				Name:       film.Name,
+				CreditType: credit.CreditType,
+				Character:  credit.Character,
+			})
+		}
+	}
+	sort.Slice(response.Films, func(i, j int) bool {
+		a, b := response.Films[i], response.Films[j]
+		if da, db := s.films[a.Id].Date, s.films[b.Id].Date; da != db {
+			return da < db
+		}
+		if a.Id != b.Id {
+			return a.Id < b.Id
+		}
+		return a.CreditType < b.CreditType
+	})
+	return response
+}
+
+// GetFilmsActor returns a page of actors ordered by surname, name and id along with their credited films.
+func (s *Storage) GetFilmsActor(ctx context.Context, q structures.ActorListQuery) (structures.ActorList, error) {
+	list := structures.ActorList{Actors: []structures.ActorResponse{}}
+	if err := repository.NormalizePage(&q.Limit, q.Offset); err != nil {
+		return list, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var actors []structures.Actor
+	for _, actor := range s.actors {
+		if strings.Contains(strings.ToLower(fullName(actor)), strings.ToLower(q.Name)) {
+			actors = append(actors, actor)
+		}
+	}
+	sort.Slice(actors, func(i, j int) bool {
+		a, b := actors[i], actors[j]
+		if a.Surname != b.Surname {
+			return a.Surname < b.Surname
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Id < b.Id
+	})
+	list.Total = len(actors)
+	for _, actor := range page(actors, q.Limit, q.Offset) {
+		list.Actors = append(list.Actors, s.actorResponse(actor))
+	}
+	return list, nil
+}
@@ -0,0 +1,38 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"VK_app/internal/structures"
+)
+
+// BenchmarkGetFilmsActor lists pages of 10000 actors credited in 2000 films.
+func BenchmarkGetFilmsActor(b *testing.B) {
+	ctx := context.Background()
+	s := New()
+	for i := 0; i < 2000; i++ {
+		s.AddFilm(ctx, structures.Film{Name: fmt.Sprintf("Фильм %d", i), Date: "20200101"})
+	}
+	for i := 0; i < 10000; i++ {
+		s.AddActor(ctx, structures.Actor{Name: fmt.Sprintf("Имя%d", i), Surname: fmt.Sprintf("Фамилия%d", i%1000), BirthDate: "19800101", Sex: "m"})
+		for j := 0; j < 5; j++ {
+			s.AddActorFilm(ctx, structures.ActorFilm{ActorID: i + 1, FilmID: (i*5+j)%2000 + 1})
+		}
+	}
+	queries := []structures.ActorListQuery{
+		{Limit: 20},
+		{Limit: 20, Offset: 5000},
+		{Name: "фамилия42", Limit: 20},
+	}
+	for _, q := range queries {
+		b.Run(fmt.Sprintf("name=%q/offset=%d", q.Name, q.Offset), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := s.GetFilmsActor(ctx, q); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// collection is a stored collection, films holds the film ids in order.
+type collection struct {
+	structures.Collection
+	films []int
+}
+
+// CreateCollection stores a new collection with no films, slugs are unique.
+func (s *Storage) CreateCollection(ctx context.Context, c structures.Collection) error {
+	if err := repository.ValidateCollection(c); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[c.Login]; !ok {
+		return repository.ErrNotFound
+	}
+	if _, ok := s.collectionBySlug(c.Slug); ok {
+		return repository.ErrAlreadyExists
+	}
+	s.collectionSeq++
+	c.Id = s.collectionSeq
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = c.CreatedAt
+	c.Films, c.FilmCount = nil, 0
+	s.collections[c.Id] = collection{Collection: c}
+	return nil
+}
+
+// GetCollection returns the collection along with its films in order.
+// Private collections are only returned to their owner.
+func (s *Storage) GetCollection(ctx context.Context, slug, viewer string) (structures.Collection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.collectionBySlug(slug)
+	if !ok || !c.Public && c.Login != viewer {
+		return structures.Collection{}, repository.ErrNotFound
+	}
+	result := c.summary()
+	for _, id := range c.films {
+		result.Films = append(result.Films, s.filmWithGenres(id))
+	}
+	return result, nil
+}
+
+// ListPublicCollections returns a page of public collections whose name contains the query, ignoring case,
+// the latest updated first.
+func (s *Storage) ListPublicCollections(ctx context.Context, q structures.CollectionListQuery) (structures.CollectionList, error) {
+	query := strings.ToLower(q.Query)
+	return s.listCollections(q.Limit, q.Offset, func(c collection) bool {
+		return c.Public && (q.Login == "" || c.Login == q.Login) &&
+			(query == "" || strings.Contains(strings.ToLower(c.Name), query))
+	})
+}
+
+// GetUserCollections returns a page of the public and private collections of the user, the latest updated first.
+func (s *Storage) GetUserCollections(ctx context.Context, login string, limit, offset int) (structures.CollectionList, error) {
+	return s.listCollections(limit, offset, func(c collection) bool { return c.Login == login })
+}
+
+func (s *Storage) listCollections(limit, offset int, match func(c collection) bool) (structures.CollectionList, error) {
+	var list structures.CollectionList
+	if err := repository.NormalizePage(&limit, offset); err != nil {
+		return list, err
+	}
+	s.mu.RLock()
+	var collections []structures.Collection
+	for _, c := range s.collections {
+		if match(c) {
+			collections = append(collections, c.summary())
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(collections, func(i, j int) bool {
+		if !collections[i].UpdatedAt.Equal(collections[j].UpdatedAt) {
+			return collections[i].UpdatedAt.After(collections[j].UpdatedAt)
+		}
+		return collections[i].Id > collections[j].Id
+	})
+	list.Total = len(collections)
+	list.Collections = append([]structures.Collection{}, page(collections, limit, offset)...)
+	return list, nil
+}
+
+// UpdateCollection changes the non-nil fields of the collection of the user.
+func (s *Storage) UpdateCollection(ctx context.Context, login, slug string, update structures.CollectionUpdate) error {
+	if err := repository.ValidateCollectionUpdate(update); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.ownedCollection(login, slug)
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if update.Name != nil {
+		c.Name = *update.Name
+	}
+	if update.Description != nil {
+		c.Description = *update.Description
+	}
+	if update.Public != nil {
+		c.Public = *update.Public
+	}
+	c.UpdatedAt = time.Now()
+	s.collections[c.Id] = c
+	return nil
+}
+
+// DelCollection deletes the collection of the user.
+func (s *Storage) DelCollection(ctx context.Context, login, slug string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.ownedCollection(login, slug)
+	if !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.collections, c.Id)
+	return nil
+}
+
+// AddCollectionFilm appends the film to the collection of the user.
+func (s *Storage) AddCollectionFilm(ctx context.Context, login, slug string, filmID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.ownedCollection(login, slug)
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if _, ok := s.films[filmID]; !ok {
+		return repository.ErrNotFound
+	}
+	for _, id := range c.films {
+		if id == filmID {
+			return repository.ErrAlreadyExists
+		}
+	}
+	c.films = append(append([]int{}, c.films...), filmID)
+	c.UpdatedAt = time.Now()
+	s.collections[c.Id] = c
+	return nil
+}
+
+// DelCollectionFilm removes the film from the collection of the user.
+func (s *Storage) DelCollectionFilm(ctx context.Context, login, slug string, filmID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.ownedCollection(login, slug)
+	if !ok {
+		return repository.ErrNotFound
+	}
+	films := withoutFilm(c.films, filmID)
+	if len(films) == len(c.films) {
+		return repository.ErrNotFound
+	}
+	c.films = films
+	c.UpdatedAt = time.Now()
+	s.collections[c.Id] = c
+	return nil
+}
+
+// ReorderCollection puts the films of the collection of the user in the given order.
+func (s *Storage) ReorderCollection(ctx context.Context, login, slug string, filmIDs []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.ownedCollection(login, slug)
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := repository.ValidateOrder(c.films, filmIDs); err != nil {
+		return err
+	}
+	c.films = append([]int{}, filmIDs...)
+	c.UpdatedAt = time.Now()
+	s.collections[c.Id] = c
+	return nil
+}
+
+// summary returns the collection without its films.
+func (c collection) summary() structures.Collection {
+	result := c.Collection
+	result.FilmCount = len(c.films)
+	return result
+}
+
+// collectionBySlug returns the collection with the slug. The caller must hold the lock.
+func (s *Storage) collectionBySlug(slug string) (collection, bool) {
+	for _, c := range s.collections {
+		if c.Slug == slug {
+			return c, true
+		}
+	}
+	return collection{}, false
+}
+
+// ownedCollection returns the collection with the slug if the user owns it. The caller must hold the lock.
+func (s *Storage) ownedCollection(login, slug string) (collection, bool) {
+	c, ok := s.collectionBySlug(slug)
+	return c, ok && c.Login == login
+}
+
+// withoutFilm returns a copy of the film ids without the film.
+func withoutFilm(films []int, filmID int) []int {
+	result := make([]int, 0, len(films))
+	for _, id := range films {
+		if id != filmID {
+			result = append(result, id)
+		}
+	}
+	return result
+}
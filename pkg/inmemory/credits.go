@@ -0,0 +1,104 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// creditKey identifies a credit the same way the actorsfilms primary key does.
+type creditKey struct {
+	ActorID    int
+	FilmID     int
+	CreditType string
+}
+
+func keyOf(credit structures.ActorFilm) creditKey {
+	return creditKey{ActorID: credit.ActorID, FilmID: credit.FilmID, CreditType: credit.CreditType}
+}
+
+// AddActorFilm credits an actor in a film, both of them must exist.
+func (s *Storage) AddActorFilm(ctx context.Context, actorFilm structures.ActorFilm) error {
+	if err := repository.NormalizeCredit(&actorFilm); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.actors[actorFilm.ActorID]; !ok {
+		return repository.ErrNotFound
+	}
+	if _, ok := s.films[actorFilm.FilmID]; !ok {
+		return repository.ErrNotFound
+	}
+	if _, ok := s.actorsFilms[keyOf(actorFilm)]; ok {
+		return repository.ErrAlreadyExists
+	}
+	s.actorsFilms[keyOf(actorFilm)] = actorFilm
+	return nil
+}
+
+// UpdateActorFilm replaces the character and the billing position of a credit.
+func (s *Storage) UpdateActorFilm(ctx context.Context, actorFilm structures.ActorFilm) error {
+	if err := repository.NormalizeCredit(&actorFilm); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.actorsFilms[keyOf(actorFilm)]; !ok {
+		return repository.ErrNotFound
+	}
+	s.actorsFilms[keyOf(actorFilm)] = actorFilm
+	return nil
+}
+
+// DelActorFilm removes a credit of an actor in a film.
+func (s *Storage) DelActorFilm(ctx context.Context, actorFilm structures.ActorFilm) error {
+	if err := repository.NormalizeCredit(&actorFilm); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.actorsFilms[keyOf(actorFilm)]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.actorsFilms, keyOf(actorFilm))
+	return nil
+}
+
+// GetFilmCredits returns the cast and crew of the film ordered by credit type and billing.
+func (s *Storage) GetFilmCredits(ctx context.Context, filmID int) ([]structures.CreditResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	credits := []structures.CreditResponse{}
+	for _, credit := range s.actorsFilms {
+		if credit.FilmID != filmID {
+			continue
+		}
+		actor := s.actors[credit.ActorID]
+		credits = append(credits, structures.CreditResponse{
+			ActorID:    actor.Id,
+			Name:       actor.Name,
+			Surname:    actor.Surname,
+			CreditType: credit.CreditType,
+			Character:  credit.Character,
+			Billing:    credit.Billing,
+		})
+	}
+	sort.Slice(credits, func(i, j int) bool {
+		a, b := credits[i], credits[j]
+		if a.CreditType != b.CreditType {
+			return a.CreditType < b.CreditType
+		}
+		// unbilled credits go last like NULLS LAST does
+		if (a.Billing == 0) != (b.Billing == 0) {
+			return b.Billing == 0
+		}
+		if a.Billing != b.Billing {
+			return a.Billing < b.Billing
+		}
+		return a.ActorID < b.ActorID
+	})
+	return credits, nil
+}
@@ -0,0 +1,109 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// compareFilms orders two films by the sort keys followed by the id.
+func compareFilms(a, b structures.Film, keys []structures.SortKey) int {
+	for _, key := range keys {
+		var c int
+		switch key.Field {
+		case "rating":
+			c = compareFloat(a.Rating, b.Rating)
+		case "name":
+			c = strings.Compare(a.Name, b.Name)
+		case "date":
+			c = strings.Compare(a.Date, b.Date)
+		}
+		if key.Order == "desc" {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return a.Id - b.Id
+}
+
+func compareFloat(a, b float32) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// matchesFilters reports whether the film passes the rating and date ranges of the query.
+func matchesFilters(f structures.Film, q structures.FilmListQuery) bool {
+	if q.RatingFrom != nil && f.Rating < *q.RatingFrom {
+		return false
+	}
+	if q.RatingTo != nil && f.Rating > *q.RatingTo {
+		return false
+	}
+	if q.DateFrom != "" && f.Date < q.DateFrom {
+		return false
+	}
+	if q.DateTo != "" && f.Date > q.DateTo {
+		return false
+	}
+	return true
+}
+
+// ListFilms returns one page of films matching the query along with the total count.
+func (s *Storage) ListFilms(ctx context.Context, q structures.FilmListQuery) (structures.FilmList, error) {
+	var list structures.FilmList
+	if err := repository.NormalizeFilmListQuery(&q); err != nil {
+		return list, err
+	}
+	var after *structures.Film
+	if q.Cursor != "" {
+		film, err := repository.DecodeFilmCursor(q.Cursor, q.Sort)
+		if err != nil {
+			return list, err
+		}
+		after = &film
+	}
+
+	s.mu.RLock()
+	films := s.filterFilms(func(f structures.Film) bool { return matchesFilters(f, q) && s.hasGenre(f.Id, q.Genre) })
+	s.mu.RUnlock()
+
+	list.Total = len(films)
+	list.Films = []structures.Film{}
+	sort.Slice(films, func(i, j int) bool { return compareFilms(films[i], films[j], q.Sort) < 0 })
+	if after != nil {
+		start := sort.Search(len(films), func(i int) bool { return compareFilms(films[i], *after, q.Sort) > 0 })
+		films = films[start:]
+	}
+	if q.Offset >= len(films) {
+		return list, nil
+	}
+	films = films[q.Offset:]
+	if len(films) > q.Limit {
+		films = films[:q.Limit]
+		list.NextCursor = repository.EncodeFilmCursor(films[len(films)-1], q.Sort)
+	}
+	list.Films = films
+	return list, nil
+}
+
+// GetFilm returns the film with the given id along with its genres.
+func (s *Storage) GetFilm(ctx context.Context, id int) (structures.Film, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	film, ok := s.films[id]
+	if !ok {
+		return structures.Film{}, repository.ErrNotFound
+	}
+	film.Genres = s.filmGenres(id)
+	return film, nil
+}
@@ -0,0 +1,136 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// AddGenre stores a new genre under the next free id, genre names are unique.
+func (s *Storage) AddGenre(ctx context.Context, genre structures.Genre) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.genreNameTaken(genre.Name, 0) {
+		return repository.ErrAlreadyExists
+	}
+	s.genreSeq++
+	genre.Id = s.genreSeq
+	s.genres[genre.Id] = genre
+	return nil
+}
+
+// UpdateGenre renames the stored genre.
+func (s *Storage) UpdateGenre(ctx context.Context, genre structures.Genre) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.genres[genre.Id]; !ok {
+		return repository.ErrNotFound
+	}
+	if genre.Name == "" {
+		return nil
+	}
+	if s.genreNameTaken(genre.Name, genre.Id) {
+		return repository.ErrAlreadyExists
+	}
+	s.genres[genre.Id] = genre
+	return nil
+}
+
+// DelGenre deletes the genre and all its links to films.
+func (s *Storage) DelGenre(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.genres, id)
+	for link := range s.filmsGenres {
+		if link.GenreID == id {
+			delete(s.filmsGenres, link)
+		}
+	}
+	return nil
+}
+
+// CheckGenre returns repository.ErrNotFound if there is no genre with the given id.
+func (s *Storage) CheckGenre(ctx context.Context, id int) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.genres[id]; !ok {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// GetGenres returns all genres ordered by name.
+func (s *Storage) GetGenres(ctx context.Context) ([]structures.Genre, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	genres := []structures.Genre{}
+	for _, genre := range s.genres {
+		genres = append(genres, genre)
+	}
+	sort.Slice(genres, func(i, j int) bool { return genres[i].Name < genres[j].Name })
+	return genres, nil
+}
+
+// AddFilmGenre links a film to a genre, both of them must exist.
+func (s *Storage) AddFilmGenre(ctx context.Context, filmGenre structures.FilmGenre) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.films[filmGenre.FilmID]; !ok {
+		return repository.ErrNotFound
+	}
+	if _, ok := s.genres[filmGenre.GenreID]; !ok {
+		return repository.ErrNotFound
+	}
+	if _, ok := s.filmsGenres[filmGenre]; ok {
+		return repository.ErrAlreadyExists
+	}
+	s.filmsGenres[filmGenre] = struct{}{}
+	return nil
+}
+
+// DelFilmGenre removes the link between a film and a genre.
+func (s *Storage) DelFilmGenre(ctx context.Context, filmGenre structures.FilmGenre) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.filmsGenres, filmGenre)
+	return nil
+}
+
+// genreNameTaken reports whether another genre already has the name. The caller must hold the lock.
+func (s *Storage) genreNameTaken(name string, exceptID int) bool {
+	for _, genre := range s.genres {
+		if genre.Id != exceptID && genre.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// filmGenres returns the sorted genre names of the film. The caller must hold the lock.
+func (s *Storage) filmGenres(filmID int) []string {
+	var names []string
+	for link := range s.filmsGenres {
+		if link.FilmID == filmID {
+			names = append(names, s.genres[link.GenreID].Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hasGenre reports whether the film belongs to the genre, an empty genre matches every film.
+// The caller must hold the lock.
+func (s *Storage) hasGenre(filmID int, genre string) bool {
+	if genre == "" {
+		return true
+	}
+	for link := range s.filmsGenres {
+		if link.FilmID == filmID && strings.EqualFold(s.genres[link.GenreID].Name, genre) {
+			return true
+		}
+	}
+	return false
+}
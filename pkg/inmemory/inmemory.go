@@ -0,0 +1,293 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// Storage is an in-memory implementation of the film, actor and user repositories.
+//
+// It mirrors the behaviour of the PostgreSQL schema: ids are generated sequentially,
+// deleting a film, an actor or a genre cascades to the link tables and logins
+// and genre names are unique. Its operations never wait on I/O, so they ignore their contexts.
+type Storage struct {
+	mu            sync.RWMutex
+	films         map[int]structures.Film
+	actors        map[int]structures.Actor
+	actorsFilms   map[creditKey]structures.ActorFilm
+	genres        map[int]structures.Genre
+	filmsGenres   map[structures.FilmGenre]struct{}
+	reviews       map[int]structures.Review
+	users         map[string]structures.User
+	profiles      map[string]structures.Profile
+	roles         map[string][]string
+	sessions      map[string]session
+	tokens        map[string]refreshToken
+	resets        map[string]passwordReset
+	watchlist     map[watchKey]time.Time
+	viewings      map[viewingKey]struct{}
+	collections   map[int]collection
+	filmSeq       int
+	actorSeq      int
+	genreSeq      int
+	reviewSeq     int
+	collectionSeq int
+}
+
+// New returns an empty Storage.
+func New() *Storage {
+	return &Storage{
+		films:       map[int]structures.Film{},
+		actors:      map[int]structures.Actor{},
+		actorsFilms: map[creditKey]structures.ActorFilm{},
+		genres:      map[int]structures.Genre{},
+		filmsGenres: map[structures.FilmGenre]struct{}{},
+		reviews:     map[int]structures.Review{},
+		users:       map[string]structures.User{},
+		profiles:    map[string]structures.Profile{},
+		roles:       defaultRoles(),
+		sessions:    map[string]session{},
+		tokens:      map[string]refreshToken{},
+		resets:      map[string]passwordReset{},
+		watchlist:   map[watchKey]time.Time{},
+		viewings:    map[viewingKey]struct{}{},
+		collections: map[int]collection{},
+	}
+}
+
+var (
+	_ repository.FilmRepository           = (*Storage)(nil)
+	_ repository.ActorRepository          = (*Storage)(nil)
+	_ repository.GenreRepository          = (*Storage)(nil)
+	_ repository.ReviewRepository         = (*Storage)(nil)
+	_ repository.RoleRepository           = (*Storage)(nil)
+	_ repository.SessionRepository        = (*Storage)(nil)
+	_ repository.PasswordResetRepository  = (*Storage)(nil)
+	_ repository.UserRepository           = (*Storage)(nil)
+	_ repository.WatchlistRepository      = (*Storage)(nil)
+	_ repository.CollectionRepository     = (*Storage)(nil)
+	_ repository.RecommendationRepository = (*Storage)(nil)
+)
+
+// AddUser stores a new user, logins must be unique and the role must exist.
+func (s *Storage) AddUser(ctx context.Context, u structures.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[u.Login]; ok {
+		return repository.ErrAlreadyExists
+	}
+	if _, ok := s.roles[u.Role]; !ok {
+		return repository.ErrNotFound
+	}
+	s.users[u.Login] = u
+	s.profiles[u.Login] = structures.Profile{Login: u.Login, Language: repository.DefaultLanguage, CreatedAt: time.Now()}
+	return nil
+}
+
+// GetUser returns the user with the given login.
+func (s *Storage) GetUser(ctx context.Context, login string) (structures.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, ok := s.users[login]
+	if !ok {
+		return structures.User{}, repository.ErrNotFound
+	}
+	return u, nil
+}
+
+// UpdatePassword replaces the password hash of the user.
+func (s *Storage) UpdatePassword(ctx context.Context, login, password string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[login]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	u.Password = password
+	s.users[login] = u
+	return nil
+}
+
+// AddFilm stores a new film under the next free id, it starts with no rating.
+func (s *Storage) AddFilm(ctx context.Context, film structures.Film) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.filmSeq++
+	film.Id = s.filmSeq
+	film.Rating = 0
+	film.Votes = 0
+	film.Genres = nil
+	s.films[film.Id] = film
+	return nil
+}
+
+// UpdateFilm overwrites the non-empty fields of the stored film except the computed rating.
+func (s *Storage) UpdateFilm(ctx context.Context, film structures.Film) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.films[film.Id]
+	if !ok {
+		return nil
+	}
+	if film.Name != "" {
+		stored.Name = film.Name
+	}
+	if film.Description != "" {
+		stored.Description = film.Description
+	}
+	if film.Date != "" {
+		stored.Date = film.Date
+	}
+	s.films[film.Id] = stored
+	return nil
+}
+
+// DelFilm deletes the film along with its credits, genre links and reviews.
+func (s *Storage) DelFilm(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.films, id)
+	for link := range s.actorsFilms {
+		if link.FilmID == id {
+			delete(s.actorsFilms, link)
+		}
+	}
+	for link := range s.filmsGenres {
+		if link.FilmID == id {
+			delete(s.filmsGenres, link)
+		}
+	}
+	for reviewID, review := range s.reviews {
+		if review.FilmID == id {
+			delete(s.reviews, reviewID)
+		}
+	}
+	for key := range s.watchlist {
+		if key.filmID == id {
+			delete(s.watchlist, key)
+		}
+	}
+	for key := range s.viewings {
+		if key.filmID == id {
+			delete(s.viewings, key)
+		}
+	}
+	for collectionID, c := range s.collections {
+		c.films = withoutFilm(c.films, id)
+		s.collections[collectionID] = c
+	}
+	return nil
+}
+
+// CheckFilm returns repository.ErrNotFound if there is no film with the given id.
+func (s *Storage) CheckFilm(ctx context.Context, id int) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.films[id]; !ok {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// GetFilmsPieceFilm returns films whose name contains the piece ignoring case.
+func (s *Storage) GetFilmsPieceFilm(ctx context.Context, piece, genre string) ([]structures.Film, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.filterFilms(func(f structures.Film) bool {
+		return strings.Contains(strings.ToLower(f.Name), strings.ToLower(piece)) && s.hasGenre(f.Id, genre)
+	}), nil
+}
+
+// GetFilmsPieceActor returns films featuring an actor whose full name contains the piece ignoring case.
+func (s *Storage) GetFilmsPieceActor(ctx context.Context, piece, genre string) ([]structures.Film, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	filmIDs := map[int]bool{}
+	for link := range s.actorsFilms {
+		name := strings.ToLower(fullName(s.actors[link.ActorID]))
+		if link.CreditType == repository.CreditActor && strings.Contains(name, strings.ToLower(piece)) {
+			filmIDs[link.FilmID] = true
+		}
+	}
+	return s.filterFilms(func(f structures.Film) bool { return filmIDs[f.Id] && s.hasGenre(f.Id, genre) }), nil
+}
+
+// AddActor stores a new actor under the next free id.
+func (s *Storage) AddActor(ctx context.Context, actor structures.Actor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.actorSeq++
+	actor.Id = s.actorSeq
+	s.actors[actor.Id] = actor
+	return nil
+}
+
+// UpdateActor overwrites the non-empty fields of the stored actor.
+func (s *Storage) UpdateActor(ctx context.Context, actor structures.Actor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.actors[actor.Id]
+	if !ok {
+		return nil
+	}
+	if actor.Name != "" {
+		stored.Name = actor.Name
+	}
+	if actor.Surname != "" {
+		stored.Surname = actor.Surname
+	}
+	if actor.FatherName != "" {
+		stored.FatherName = actor.FatherName
+	}
+	if actor.BirthDate != "" {
+		stored.BirthDate = actor.BirthDate
+	}
+	if actor.Sex != "" {
+		stored.Sex = actor.Sex
+	}
+	s.actors[actor.Id] = stored
+	return nil
+}
+
+// DelActor deletes the actor and all its links to films.
+func (s *Storage) DelActor(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.actors, id)
+	for link := range s.actorsFilms {
+		if link.ActorID == id {
+			delete(s.actorsFilms, link)
+		}
+	}
+	return nil
+}
+
+// CheckActor returns repository.ErrNotFound if there is no actor with the given id.
+func (s *Storage) CheckActor(ctx context.Context, id int) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.actors[id]; !ok {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// filterFilms returns the films matching keep ordered by id along with their genres.
+// The caller must hold the lock.
+func (s *Storage) filterFilms(keep func(structures.Film) bool) []structures.Film {
+	var films []structures.Film
+	for _, film := range s.films {
+		if keep(film) {
+			film.Genres = s.filmGenres(film.Id)
+			films = append(films, film)
+		}
+	}
+	sort.Slice(films, func(i, j int) bool { return films[i].Id < films[j].Id })
+	return films
+}
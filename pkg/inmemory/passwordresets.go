@@ -0,0 +1,61 @@
+package inmemory
+
+import (
+	"context"
+	"time"
+
+	"VK_app/pkg/repository"
+)
+
+// passwordReset is a stored password reset token.
+type passwordReset struct {
+	login     string
+	expiresAt time.Time
+	used      bool
+}
+
+// CreatePasswordReset stores a reset token of the user, replacing the earlier unused ones.
+func (s *Storage) CreatePasswordReset(ctx context.Context, hash, login string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[login]; !ok {
+		return repository.ErrNotFound
+	}
+	for h, reset := range s.resets {
+		if reset.login == login && !reset.used {
+			delete(s.resets, h)
+		}
+	}
+	s.resets[hash] = passwordReset{login: login, expiresAt: expiresAt}
+	return nil
+}
+
+// GetPasswordResetLogin returns the login of the user of a reset token that can still be used.
+func (s *Storage) GetPasswordResetLogin(ctx context.Context, hash string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	reset, ok := s.resets[hash]
+	if !ok || reset.used || !reset.expiresAt.After(time.Now()) {
+		return "", repository.ErrNotFound
+	}
+	return reset.login, nil
+}
+
+// ResetPassword uses up the reset token and replaces the password hash of its user.
+func (s *Storage) ResetPassword(ctx context.Context, hash, password string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reset, ok := s.resets[hash]
+	if !ok || reset.used || !reset.expiresAt.After(time.Now()) {
+		return "", repository.ErrNotFound
+	}
+	u, ok := s.users[reset.login]
+	if !ok {
+		return "", repository.ErrNotFound
+	}
+	reset.used = true
+	s.resets[hash] = reset
+	u.Password = password
+	s.users[reset.login] = u
+	return reset.login, nil
+}
@@ -0,0 +1,122 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/recommend"
+)
+
+// GetRelatedFilms returns the features of the given films and of at most limit other films
+// sharing the most with them, ordered by id. weights[i] is the weight of filmIDs[i].
+func (s *Storage) GetRelatedFilms(ctx context.Context, filmIDs []int, weights []float64, limit int) ([]structures.FilmFeatures, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seeds := make(map[int]bool, len(filmIDs))
+	var features []structures.FilmFeatures
+	var seedWeights []float64
+	for i, id := range filmIDs {
+		if _, ok := s.films[id]; ok && !seeds[id] {
+			features = append(features, s.filmFeatures(id))
+			seedWeights = append(seedWeights, weights[i])
+		}
+		seeds[id] = true
+	}
+	type candidate struct {
+		structures.FilmFeatures
+		score float64
+	}
+	var candidates []candidate
+	for id := range s.films {
+		if seeds[id] {
+			continue
+		}
+		c := candidate{FilmFeatures: s.filmFeatures(id)}
+		for i, seed := range features {
+			c.score += seedWeights[i] * recommend.Compare(seed, c.FilmFeatures).Score
+		}
+		if c.score > 0 {
+			candidates = append(candidates, c)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if a.Film.Rating != b.Film.Rating {
+			return a.Film.Rating > b.Film.Rating
+		}
+		return a.Film.Id < b.Film.Id
+	})
+	for i, c := range candidates {
+		if i == limit {
+			break
+		}
+		features = append(features, c.FilmFeatures)
+	}
+	sort.Slice(features, func(i, j int) bool { return features[i].Film.Id < features[j].Film.Id })
+	return features, nil
+}
+
+// filmFeatures returns the film along with its genres and credited people. The caller must hold the lock.
+func (s *Storage) filmFeatures(id int) structures.FilmFeatures {
+	return structures.FilmFeatures{Film: s.filmWithGenres(id), People: s.creditedPeople(id)}
+}
+
+// creditedPeople returns the people credited in the film ordered by credit type, billing and id.
+// The caller must hold the lock.
+func (s *Storage) creditedPeople(filmID int) []structures.CreditedPerson {
+	var credits []structures.ActorFilm
+	for key, credit := range s.actorsFilms {
+		if key.FilmID == filmID {
+			credits = append(credits, credit)
+		}
+	}
+	sort.Slice(credits, func(i, j int) bool {
+		a, b := credits[i], credits[j]
+		if a.CreditType != b.CreditType {
+			return a.CreditType < b.CreditType
+		}
+		if (a.Billing == 0) != (b.Billing == 0) {
+			return b.Billing == 0
+		}
+		if a.Billing != b.Billing {
+			return a.Billing < b.Billing
+		}
+		return a.ActorID < b.ActorID
+	})
+	people := make([]structures.CreditedPerson, len(credits))
+	for i, credit := range credits {
+		people[i] = structures.CreditedPerson{ActorID: credit.ActorID, FullName: fullName(s.actors[credit.ActorID]), CreditType: credit.CreditType}
+	}
+	return people
+}
+
+// GetUserSignals returns the films the user rated or watched, ordered by film id.
+func (s *Storage) GetUserSignals(ctx context.Context, login string) ([]structures.UserSignal, error) {
+	s.mu.RLock()
+	byFilm := map[int]structures.UserSignal{}
+	for _, review := range s.reviews {
+		if review.Login == login {
+			signal := byFilm[review.FilmID]
+			signal.FilmID, signal.Score = review.FilmID, review.Score
+			byFilm[review.FilmID] = signal
+		}
+	}
+	for key := range s.viewings {
+		if key.login == login {
+			signal := byFilm[key.filmID]
+			signal.FilmID, signal.Watched = key.filmID, true
+			byFilm[key.filmID] = signal
+		}
+	}
+	s.mu.RUnlock()
+	signals := make([]structures.UserSignal, 0, len(byFilm))
+	for _, signal := range byFilm {
+		signals = append(signals, signal)
+	}
+	sort.Slice(signals, func(i, j int) bool { return signals[i].FilmID < signals[j].FilmID })
+	return signals, nil
+}
@@ -0,0 +1,63 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"VK_app/internal/structures"
+)
+
+func TestGetRelatedFilms(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	for i := 1; i <= 6; i++ {
+		s.AddFilm(ctx, structures.Film{Name: fmt.Sprintf("Фильм %d", i), Date: "20200101"})
+	}
+	s.AddGenre(ctx, structures.Genre{Name: "драма"})
+	for film := 1; film <= 5; film++ {
+		s.AddFilmGenre(ctx, structures.FilmGenre{FilmID: film, GenreID: 1})
+	}
+	s.AddActor(ctx, structures.Actor{Name: "Киану", Surname: "Ривз", BirthDate: "19640902", Sex: "m"})
+	s.AddActor(ctx, structures.Actor{Name: "Лана", Surname: "Вачовски", BirthDate: "19650621", Sex: "f"})
+	for _, credit := range []structures.ActorFilm{
+		{ActorID: 1, FilmID: 1, CreditType: "actor"},
+		{ActorID: 1, FilmID: 3, CreditType: "actor"},
+		{ActorID: 2, FilmID: 1, CreditType: "director"},
+		{ActorID: 2, FilmID: 4, CreditType: "director"},
+	} {
+		if err := s.AddActorFilm(ctx, credit); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// against film 1 the genre scores films 2 to 5, the actor adds to 3 and the director to 4
+	tests := []struct {
+		name    string
+		ids     []int
+		weights []float64
+		limit   int
+		want    []int
+	}{
+		{"every related film", []int{1}, []float64{1}, 10, []int{1, 2, 3, 4, 5}},
+		{"best related films", []int{1}, []float64{1}, 2, []int{1, 3, 4}},
+		{"a disliked film lowers its related films", []int{1, 5}, []float64{1, -1}, 10, []int{1, 3, 4, 5}},
+		{"no related films", []int{6}, []float64{1}, 10, []int{6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			features, err := s.GetRelatedFilms(ctx, tt.ids, tt.weights, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := []int{}
+			for _, f := range features {
+				ids = append(ids, f.Film.Id)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Fatalf("GetRelatedFilms returned films %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// UpsertReview creates the review of the user for the film or replaces its score and text,
+// then recomputes the film rating.
+func (s *Storage) UpsertReview(ctx context.Context, review structures.Review) error {
+	if err := repository.ValidateReview(review); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.films[review.FilmID]; !ok {
+		return repository.ErrNotFound
+	}
+	if _, ok := s.users[review.Login]; !ok {
+		return repository.ErrNotFound
+	}
+	now := time.Now()
+	review.CreatedAt, review.UpdatedAt = now, now
+	if id, ok := s.userReview(review.FilmID, review.Login); ok {
+		review.Id = id
+		review.CreatedAt = s.reviews[id].CreatedAt
+	} else {
+		s.reviewSeq++
+		review.Id = s.reviewSeq
+	}
+	s.reviews[review.Id] = review
+	s.updateRating(review.FilmID)
+	return nil
+}
+
+// GetFilmReviews returns a page of the film reviews, the newest first, along with their total count.
+func (s *Storage) GetFilmReviews(ctx context.Context, filmID, limit, offset int) (structures.ReviewList, error) {
+	var list structures.ReviewList
+	if err := repository.NormalizePage(&limit, offset); err != nil {
+		return list, err
+	}
+	s.mu.RLock()
+	var reviews []structures.Review
+	for _, review := range s.reviews {
+		if review.FilmID == filmID {
+			reviews = append(reviews, review)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(reviews, func(i, j int) bool {
+		if !reviews[i].CreatedAt.Equal(reviews[j].CreatedAt) {
+			return reviews[i].CreatedAt.After(reviews[j].CreatedAt)
+		}
+		return reviews[i].Id > reviews[j].Id
+	})
+	list.Total = len(reviews)
+	list.Reviews = []structures.Review{}
+	if offset >= len(reviews) {
+		return list, nil
+	}
+	reviews = reviews[offset:]
+	if len(reviews) > limit {
+		reviews = reviews[:limit]
+	}
+	list.Reviews = reviews
+	return list, nil
+}
+
+// DelReview deletes the review with the given id and recomputes the film rating.
+func (s *Storage) DelReview(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	review, ok := s.reviews[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.reviews, id)
+	s.updateRating(review.FilmID)
+	return nil
+}
+
+// DelUserReview deletes the review of the user for the film and recomputes the film rating.
+func (s *Storage) DelUserReview(ctx context.Context, filmID int, login string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.userReview(filmID, login)
+	if !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.reviews, id)
+	s.updateRating(filmID)
+	return nil
+}
+
+// userReview returns the id of the review of the user for the film. The caller must hold the lock.
+func (s *Storage) userReview(filmID int, login string) (int, bool) {
+	for id, review := range s.reviews {
+		if review.FilmID == filmID && review.Login == login {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// updateRating recomputes the rating and the votes of the film. The caller must hold the lock.
+func (s *Storage) updateRating(filmID int) {
+	film, ok := s.films[filmID]
+	if !ok {
+		return
+	}
+	sum, votes := 0, 0
+	for _, review := range s.reviews {
+		if review.FilmID == filmID {
+			sum += review.Score
+			votes++
+		}
+	}
+	film.Votes = votes
+	film.Rating = 0
+	if votes > 0 {
+		film.Rating = float32(sum) / float32(votes)
+	}
+	s.films[filmID] = film
+}
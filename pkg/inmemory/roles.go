@@ -0,0 +1,64 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// defaultRoles copies repository.DefaultRolePermissions, the role model created by the migrations.
+func defaultRoles() map[string][]string {
+	roles := make(map[string][]string, len(repository.DefaultRolePermissions))
+	for role, permissions := range repository.DefaultRolePermissions {
+		roles[role] = append([]string(nil), permissions...)
+	}
+	return roles
+}
+
+// GetRoles returns every role with its permissions, ordered by role name.
+func (s *Storage) GetRoles(ctx context.Context) ([]structures.Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	roles := make([]structures.Role, 0, len(s.roles))
+	for name, permissions := range s.roles {
+		role := structures.Role{Name: name, Permissions: append([]string{}, permissions...)}
+		sort.Strings(role.Permissions)
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+// HasPermission reports whether the role currently stored for the user grants the permission.
+func (s *Storage) HasPermission(ctx context.Context, login, permission string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, ok := s.users[login]
+	if !ok {
+		return false, nil
+	}
+	for _, p := range s.roles[u.Role] {
+		if p == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SetUserRole assigns the role to the user.
+func (s *Storage) SetUserRole(ctx context.Context, login, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[login]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if _, ok := s.roles[role]; !ok {
+		return repository.ErrNotFound
+	}
+	u.Role = role
+	s.users[login] = u
+	return nil
+}
@@ -0,0 +1,198 @@
+package inmemory
+
+import (
+	"context"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// SearchFilms approximates the PostgreSQL full-text search: a film matches if every word of
+// the query occurs in its name, description or the full name of anyone in its cast, or if the
+// film name or a cast name is trigram-similar to the query.
+func (s *Storage) SearchFilms(ctx context.Context, query string, limit, offset int) ([]structures.FilmHit, error) {
+	if err := repository.NormalizeSearch(&query, &limit, offset); err != nil {
+		return nil, err
+	}
+	words := splitWords(query)
+	s.mu.RLock()
+	var hits []structures.FilmHit
+	for _, film := range s.filterFilms(func(structures.Film) bool { return true }) {
+		rank := float32(0)
+		if containsWords(film.Name, words) {
+			rank = 1
+		} else if containsWords(film.Description, words) {
+			rank = 0.5
+		}
+		rank = max(rank, fuzzyRank(query, film.Name))
+		for _, credit := range s.actorsFilms {
+			if credit.FilmID == film.Id {
+				rank = max(rank, s.actorRank(query, words, s.actors[credit.ActorID]))
+			}
+		}
+		if rank == 0 {
+			continue
+		}
+		hits = append(hits, structures.FilmHit{
+			Film:    film,
+			Snippet: highlight(film.Name+". "+film.Description, words),
+			Rank:    rank,
+		})
+	}
+	s.mu.RUnlock()
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Rank > hits[j].Rank })
+	return page(hits, limit, offset), nil
+}
+
+// SearchActors matches the actors whose full name contains every word of the query or is trigram-similar to it.
+func (s *Storage) SearchActors(ctx context.Context, query string, limit, offset int) ([]structures.ActorHit, error) {
+	if err := repository.NormalizeSearch(&query, &limit, offset); err != nil {
+		return nil, err
+	}
+	words := splitWords(query)
+	s.mu.RLock()
+	var hits []structures.ActorHit
+	for _, actor := range s.actors {
+		if rank := s.actorRank(query, words, actor); rank > 0 {
+			hits = append(hits, structures.ActorHit{Id: actor.Id, FullName: fullName(actor), Rank: rank})
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		return hits[i].Id < hits[j].Id
+	})
+	return page(hits, limit, offset), nil
+}
+
+func (s *Storage) actorRank(query string, words []string, actor structures.Actor) float32 {
+	name := fullName(actor)
+	if containsWords(name, words) {
+		return 1
+	}
+	return fuzzyRank(query, name)
+}
+
+// fullName mirrors the actors.full_name generated column.
+func fullName(actor structures.Actor) string {
+	name := actor.Name + " " + actor.Surname
+	if actor.FatherName != "" {
+		name += " " + actor.FatherName
+	}
+	return name
+}
+
+// page cuts the page out of the sorted results.
+func page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+func splitWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// containsWords reports whether every word occurs in the text ignoring case.
+func containsWords(text string, words []string) bool {
+	text = strings.ToLower(text)
+	for _, word := range words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return len(words) > 0
+}
+
+// fuzzyRank returns the word similarity of the query to the text if it passes repository.FuzzyThreshold.
+func fuzzyRank(query, text string) float32 {
+	similarity := wordSimilarity(query, text)
+	if similarity < repository.FuzzyThreshold {
+		return 0
+	}
+	return similarity
+}
+
+// wordSimilarity approximates pg_trgm word_similarity: the best trigram similarity between
+// the query and any run of as many consecutive words of the text.
+func wordSimilarity(query, text string) float32 {
+	queryWords := splitWords(query)
+	textWords := splitWords(text)
+	if len(queryWords) == 0 || len(textWords) == 0 {
+		return 0
+	}
+	n := min(len(queryWords), len(textWords))
+	queryTrigrams := trigrams(queryWords)
+	best := float32(0)
+	for i := 0; i+n <= len(textWords); i++ {
+		best = max(best, similarity(queryTrigrams, trigrams(textWords[i:i+n])))
+	}
+	return best
+}
+
+// trigrams returns the pg_trgm trigrams of the words: every word is padded with two spaces in front and one behind.
+func trigrams(words []string) map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, word := range words {
+		runes := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			set[string(runes[i:i+3])] = struct{}{}
+		}
+	}
+	return set
+}
+
+func similarity(a, b map[string]struct{}) float32 {
+	common := 0
+	for t := range a {
+		if _, ok := b[t]; ok {
+			common++
+		}
+	}
+	return float32(common) / float32(len(a)+len(b)-common)
+}
+
+// highlight wraps the occurrences of the words in the text into the highlight markers,
+// escaping the text as HTML like the PostgreSQL storage does.
+func highlight(text string, words []string) string {
+	lower := []rune(strings.ToLower(text))
+	runes := []rune(text)
+	if len(lower) != len(runes) {
+		return html.EscapeString(text)
+	}
+	marked := make([]bool, len(runes))
+	for _, word := range words {
+		w := []rune(word)
+		for i := 0; i+len(w) <= len(lower); i++ {
+			if string(lower[i:i+len(w)]) == word {
+				for j := i; j < i+len(w); j++ {
+					marked[j] = true
+				}
+			}
+		}
+	}
+	var b strings.Builder
+	for i, r := range runes {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString(repository.HighlightStart)
+		}
+		b.WriteString(html.EscapeString(string(r)))
+		if marked[i] && (i == len(runes)-1 || !marked[i+1]) {
+			b.WriteString(repository.HighlightStop)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package inmemory
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// session is a stored session along with its revocation mark.
+type session struct {
+	structures.Session
+	revoked bool
+}
+
+// refreshToken is a stored refresh token along with its rotation mark.
+type refreshToken struct {
+	structures.RefreshToken
+	used bool
+}
+
+// CreateSession stores a new session along with its first refresh token.
+func (s *Storage) CreateSession(ctx context.Context, sess structures.Session, token structures.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[sess.Login]; !ok {
+		return repository.ErrNotFound
+	}
+	if _, ok := s.sessions[sess.Id]; ok {
+		return repository.ErrAlreadyExists
+	}
+	if sess.CreatedAt.IsZero() {
+		sess.CreatedAt = time.Now()
+	}
+	s.sessions[sess.Id] = session{Session: sess}
+	token.SessionID = sess.Id
+	s.tokens[token.Hash] = refreshToken{RefreshToken: token}
+	return nil
+}
+
+// RotateRefreshToken marks the refresh token as used and stores the next one in the same session.
+//
+// A used token presented again revokes the whole session and repository.ErrTokenReused is returned.
+func (s *Storage) RotateRefreshToken(ctx context.Context, hash string, next structures.RefreshToken) (structures.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[hash]
+	if !ok {
+		return structures.Session{}, repository.ErrNotFound
+	}
+	sess := s.sessions[token.SessionID]
+	if sess.revoked || !token.ExpiresAt.After(time.Now()) {
+		return structures.Session{}, repository.ErrNotFound
+	}
+	if token.used {
+		sess.revoked = true
+		s.sessions[sess.Id] = sess
+		log.Printf("refresh token reused, session %s of %s revoked\n", sess.Id, sess.Login)
+		return structures.Session{}, repository.ErrTokenReused
+	}
+	token.used = true
+	s.tokens[hash] = token
+	next.SessionID = sess.Id
+	s.tokens[next.Hash] = refreshToken{RefreshToken: next}
+	return sess.Session, nil
+}
+
+// RevokeSession ends the session.
+func (s *Storage) RevokeSession(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.sessions[id]; ok {
+		sess.revoked = true
+		s.sessions[id] = sess
+	}
+	return nil
+}
+
+// RevokeUserSessions ends every session of the user except the one given.
+func (s *Storage) RevokeUserSessions(ctx context.Context, login, exceptID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, sess := range s.sessions {
+		if sess.Login == login && id != exceptID {
+			sess.revoked = true
+			s.sessions[id] = sess
+		}
+	}
+	return nil
+}
+
+// IsSessionRevoked reports whether the session was revoked, unknown sessions count as revoked.
+func (s *Storage) IsSessionRevoked(ctx context.Context, id string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sess, ok := s.sessions[id]
+	return !ok || sess.revoked, nil
+}
@@ -0,0 +1,157 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// profile returns the profile of the user with the role and the state of the account. The caller must hold the lock.
+func (s *Storage) profile(u structures.User) structures.Profile {
+	p := s.profiles[u.Login]
+	p.Role, p.Disabled = u.Role, u.Disabled
+	return p
+}
+
+// GetProfile returns the profile of the user.
+func (s *Storage) GetProfile(ctx context.Context, login string) (structures.Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, ok := s.users[login]
+	if !ok {
+		return structures.Profile{}, repository.ErrNotFound
+	}
+	return s.profile(u), nil
+}
+
+// UpdateProfile changes the non-nil fields of the profile, emails are unique.
+func (s *Storage) UpdateProfile(ctx context.Context, login string, update structures.ProfileUpdate) error {
+	if err := repository.NormalizeProfileUpdate(&update); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.profiles[login]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if update.Email != nil && *update.Email != "" {
+		for other, q := range s.profiles {
+			if other != login && q.Email == *update.Email {
+				return repository.ErrAlreadyExists
+			}
+		}
+	}
+	if update.DisplayName != nil {
+		p.DisplayName = *update.DisplayName
+	}
+	if update.Email != nil {
+		p.Email = *update.Email
+	}
+	if update.Language != nil {
+		p.Language = *update.Language
+	}
+	s.profiles[login] = p
+	return nil
+}
+
+// DelUser deletes the user along with the reviews, sessions, reset tokens, watchlist, history and collections of the user,
+// then recomputes the ratings of the films the user reviewed.
+func (s *Storage) DelUser(ctx context.Context, login string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[login]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.users, login)
+	delete(s.profiles, login)
+	for id, review := range s.reviews {
+		if review.Login == login {
+			delete(s.reviews, id)
+			s.updateRating(review.FilmID)
+		}
+	}
+	for id, sess := range s.sessions {
+		if sess.Login == login {
+			delete(s.sessions, id)
+		}
+	}
+	for hash, token := range s.tokens {
+		if _, ok := s.sessions[token.SessionID]; !ok {
+			delete(s.tokens, hash)
+		}
+	}
+	for hash, reset := range s.resets {
+		if reset.login == login {
+			delete(s.resets, hash)
+		}
+	}
+	for key := range s.watchlist {
+		if key.login == login {
+			delete(s.watchlist, key)
+		}
+	}
+	for key := range s.viewings {
+		if key.login == login {
+			delete(s.viewings, key)
+		}
+	}
+	for id, c := range s.collections {
+		if c.Login == login {
+			delete(s.collections, id)
+		}
+	}
+	return nil
+}
+
+// ListUsers returns a page of users ordered by login whose login, display name or email contain the query, ignoring case.
+func (s *Storage) ListUsers(ctx context.Context, q structures.UserListQuery) (structures.UserList, error) {
+	var list structures.UserList
+	if err := repository.NormalizePage(&q.Limit, q.Offset); err != nil {
+		return list, err
+	}
+	query := strings.ToLower(q.Query)
+	s.mu.RLock()
+	var users []structures.Profile
+	for _, u := range s.users {
+		p := s.profile(u)
+		if q.Disabled != nil && p.Disabled != *q.Disabled {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(p.Login), query) &&
+			!strings.Contains(strings.ToLower(p.DisplayName), query) && !strings.Contains(strings.ToLower(p.Email), query) {
+			continue
+		}
+		users = append(users, p)
+	}
+	s.mu.RUnlock()
+	sort.Slice(users, func(i, j int) bool { return users[i].Login < users[j].Login })
+	list.Total = len(users)
+	list.Users = append([]structures.Profile{}, page(users, q.Limit, q.Offset)...)
+	return list, nil
+}
+
+// SetUserDisabled disables or re-enables the user. Disabling also ends every session of the user.
+func (s *Storage) SetUserDisabled(ctx context.Context, login string, disabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[login]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	u.Disabled = disabled
+	s.users[login] = u
+	if !disabled {
+		return nil
+	}
+	for id, sess := range s.sessions {
+		if sess.Login == login {
+			sess.revoked = true
+			s.sessions[id] = sess
+		}
+	}
+	return nil
+}
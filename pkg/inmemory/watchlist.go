@@ -0,0 +1,143 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+type watchKey struct {
+	login  string
+	filmID int
+}
+
+type viewingKey struct {
+	login  string
+	filmID int
+	date   string
+}
+
+// AddToWatchlist puts the film on the watchlist of the user.
+func (s *Storage) AddToWatchlist(ctx context.Context, login string, filmID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.films[filmID]; !ok {
+		return repository.ErrNotFound
+	}
+	if _, ok := s.users[login]; !ok {
+		return repository.ErrNotFound
+	}
+	key := watchKey{login: login, filmID: filmID}
+	if _, ok := s.watchlist[key]; ok {
+		return repository.ErrAlreadyExists
+	}
+	s.watchlist[key] = time.Now()
+	return nil
+}
+
+// RemoveFromWatchlist takes the film off the watchlist of the user.
+func (s *Storage) RemoveFromWatchlist(ctx context.Context, login string, filmID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := watchKey{login: login, filmID: filmID}
+	if _, ok := s.watchlist[key]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.watchlist, key)
+	return nil
+}
+
+// GetWatchlist returns a page of the watchlist of the user, the latest added first.
+func (s *Storage) GetWatchlist(ctx context.Context, login string, limit, offset int) (structures.Watchlist, error) {
+	var list structures.Watchlist
+	if err := repository.NormalizePage(&limit, offset); err != nil {
+		return list, err
+	}
+	s.mu.RLock()
+	var entries []structures.WatchlistEntry
+	for key, addedAt := range s.watchlist {
+		if key.login == login {
+			entries = append(entries, structures.WatchlistEntry{Film: s.filmWithGenres(key.filmID), AddedAt: addedAt})
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].AddedAt.Equal(entries[j].AddedAt) {
+			return entries[i].AddedAt.After(entries[j].AddedAt)
+		}
+		return entries[i].Film.Id > entries[j].Film.Id
+	})
+	list.Total = len(entries)
+	list.Films = append([]structures.WatchlistEntry{}, page(entries, limit, offset)...)
+	return list, nil
+}
+
+// MarkWatched records that the user watched the film on the date and takes it off the watchlist.
+func (s *Storage) MarkWatched(ctx context.Context, login string, watched structures.WatchedFilm) error {
+	if err := repository.NormalizeWatched(&watched, time.Now()); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.films[watched.FilmID]; !ok {
+		return repository.ErrNotFound
+	}
+	if _, ok := s.users[login]; !ok {
+		return repository.ErrNotFound
+	}
+	s.viewings[viewingKey{login: login, filmID: watched.FilmID, date: watched.Date}] = struct{}{}
+	delete(s.watchlist, watchKey{login: login, filmID: watched.FilmID})
+	return nil
+}
+
+// UnmarkWatched deletes the viewing of the film on the date or, if the date is empty, every viewing of it.
+func (s *Storage) UnmarkWatched(ctx context.Context, login string, watched structures.WatchedFilm) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := false
+	for key := range s.viewings {
+		if key.login == login && key.filmID == watched.FilmID && (watched.Date == "" || key.date == watched.Date) {
+			delete(s.viewings, key)
+			deleted = true
+		}
+	}
+	if !deleted {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// GetWatchHistory returns a page of the viewings of the user, the latest first.
+func (s *Storage) GetWatchHistory(ctx context.Context, login string, limit, offset int) (structures.WatchHistory, error) {
+	var history structures.WatchHistory
+	if err := repository.NormalizePage(&limit, offset); err != nil {
+		return history, err
+	}
+	s.mu.RLock()
+	var entries []structures.WatchedEntry
+	for key := range s.viewings {
+		if key.login == login {
+			entries = append(entries, structures.WatchedEntry{Film: s.filmWithGenres(key.filmID), Date: key.date})
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Date != entries[j].Date {
+			return entries[i].Date > entries[j].Date
+		}
+		return entries[i].Film.Id > entries[j].Film.Id
+	})
+	history.Total = len(entries)
+	history.Entries = append([]structures.WatchedEntry{}, page(entries, limit, offset)...)
+	return history, nil
+}
+
+// filmWithGenres returns the film along with its genres. The caller must hold the lock.
+func (s *Storage) filmWithGenres(id int) structures.Film {
+	film := s.films[id]
+	film.Genres = s.filmGenres(id)
+	return film
+}
@@ -1,20 +1,63 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
 )
 
 var Logger *log.Logger
-var LogFile *os.File
+var LogFile io.WriteCloser
 
-// LoggerInit initializes the logger by opening a file named "logger.txt" with the necessary permissions.
+// levels maps the configured log levels to slog levels. The failures are logged through the log package,
+// which writes at the info level, so warn and error would drop them and are not offered.
+var levels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+}
+
+// LoggerInit opens the log output, a file path, "stdout" or "stderr", and makes it the destination
+// of slog and of the log package, which logs at the info level. format is "text" or "json".
 //
-// Returns a pointer to the opened file.
-func LoggerInit() *os.File {
-	file, err := os.OpenFile("logger.txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
-	if err != nil {
-		log.Fatal(err)
+// Returns the opened output, closing it flushes the log.
+func LoggerInit(level, format, output string) (io.WriteCloser, error) {
+	lvl, ok := levels[level]
+	if !ok {
+		return nil, fmt.Errorf("unknown log level %q", level)
+	}
+	if format != "text" && format != "json" {
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+	var file io.WriteCloser
+	switch output {
+	case "stdout":
+		file = nopCloser{os.Stdout}
+	case "stderr":
+		file = nopCloser{os.Stderr}
+	default:
+		f, err := os.OpenFile(output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+		if err != nil {
+			return nil, err
+		}
+		file = f
+	}
+	options := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler = slog.NewTextHandler(file, options)
+	if format == "json" {
+		handler = slog.NewJSONHandler(file, options)
 	}
-	return file
+	slog.SetDefault(slog.New(handler))
+	Logger = log.Default()
+	return file, nil
+}
+
+// nopCloser keeps the standard streams open when the log is closed.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
 }
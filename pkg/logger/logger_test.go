@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"encoding/json"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoggerInitBridgesTheLogPackage(t *testing.T) {
+	defaultLogger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+	path := filepath.Join(t.TempDir(), "app.log")
+	file, err := LoggerInit("info", "json", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Println("logged through the log package")
+	slog.Debug("below the level")
+	slog.Warn("above the level")
+	file.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		messages = append(messages, record["msg"].(string))
+	}
+	want := []string{"logged through the log package", "above the level"}
+	if !reflect.DeepEqual(messages, want) {
+		t.Fatalf("logged messages are %q, want %q", messages, want)
+	}
+}
+
+func TestLoggerInitRejectsUnknownSettings(t *testing.T) {
+	// the errors logged through the log package are at the info level, warn and error would drop them
+	for _, level := range []string{"verbose", "warn", "error"} {
+		if _, err := LoggerInit(level, "text", "stderr"); err == nil {
+			t.Errorf("level %s was accepted", level)
+		}
+	}
+	if _, err := LoggerInit("info", "xml", "stderr"); err == nil {
+		t.Error("unknown format was accepted")
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"VK_app/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORS answers the cross-origin requests of the allowed origins, "*" allows any origin.
+type CORS struct {
+	origins     map[string]bool
+	any         bool
+	credentials bool
+	maxAge      string
+}
+
+// NewCORS returns the CORS middleware for the origins. With credentials the browsers send the
+// cookies of the cookie mode along, which is why "*" can not be combined with them.
+func NewCORS(origins []string, credentials bool, maxAge time.Duration) *CORS {
+	cors := &CORS{origins: map[string]bool{}, credentials: credentials, maxAge: strconv.Itoa(int(maxAge.Seconds()))}
+	for _, origin := range origins {
+		if origin == "*" {
+			cors.any = true
+		}
+		cors.origins[origin] = true
+	}
+	return cors
+}
+
+// Handle sets the CORS headers for allowed origins and answers the preflight requests.
+func (cors *CORS) Handle(c *gin.Context) {
+	origin := c.GetHeader("Origin")
+	if origin == "" || !cors.any && !cors.origins[origin] {
+		c.Next()
+		return
+	}
+	header := c.Writer.Header()
+	header.Add("Vary", "Origin")
+	if cors.any {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if cors.credentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	header.Set("Access-Control-Expose-Headers", "Authorization, Retry-After")
+	if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+		header.Set("Access-Control-Allow-Methods", strings.Join([]string{
+			http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+		}, ", "))
+		header.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, "+auth.CSRFHeader)
+		header.Set("Access-Control-Max-Age", cors.maxAge)
+		c.AbortWithStatus(http.StatusNoContent)
+		return
+	}
+	c.Next()
+}
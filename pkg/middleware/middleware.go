@@ -1,49 +1,154 @@
 package middleware
 
 import (
-	st "VK_app/internal/structures"
-
+	"errors"
 	"log"
+	"net/http"
+	"strings"
+
+	"VK_app/pkg/auth"
+	"VK_app/pkg/repository"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
 )
 
-// CheckToken is a function that takes an http.Handler and returns an http.Handler.
-//
-// It checks the validity of the token in the request header and calls the next handler if the token is valid.
-
-func CheckToken(c *gin.Context) {
-	tokenString := c.Request.Header.Get("Authorization")
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return st.Secret, nil
-	})
-	if err != nil || !token.Valid {
-		log.Println(err)
-		return
+// Codes of the errors the middleware aborts requests with.
+const (
+	CodeTokenMissing   = "token_missing"
+	CodeTokenInvalid   = "token_invalid"
+	CodeTokenExpired   = "token_expired"
+	CodeSessionRevoked = "session_revoked"
+	CodeForbidden      = "forbidden"
+	CodeCSRFFailed     = "csrf_failed"
+)
+
+// principalKey is the context key the authenticated principal is stored under.
+const principalKey = "principal"
+
+// Principal is the authenticated user of a request.
+type Principal struct {
+	Login     string
+	Role      string
+	SessionID string
+}
+
+// GetPrincipal returns the principal stored by CheckToken.
+func GetPrincipal(c *gin.Context) (Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return Principal{}, false
 	}
-	c.Set("isAuthorizedUser", true)
-	c.Next()
+	principal, ok := value.(Principal)
+	return principal, ok
+}
+
+// abort stops the request with a structured error.
+func abort(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, gin.H{"error": message, "code": code})
+}
+
+// Authenticator verifies access tokens and consults the sessions repository for revoked sessions.
+type Authenticator struct {
+	keys     *auth.KeySet
+	sessions repository.SessionRepository
 }
 
-// CheckTokenAdmin checks the token in the Gin context for admin access.
+// NewAuthenticator returns an Authenticator verifying tokens with the keys and checking sessions in the given repository.
+func NewAuthenticator(keys *auth.KeySet, sessions repository.SessionRepository) *Authenticator {
+	return &Authenticator{keys: keys, sessions: sessions}
+}
+
+// accessToken returns the access token of the request and whether it came from the cookie.
 //
-// Parameter(s):
+// The Authorization header takes the "Bearer <token>" form, a bare token is still accepted for older clients.
+func accessToken(c *gin.Context) (string, bool) {
+	if header := c.GetHeader("Authorization"); header != "" {
+		if scheme, token, ok := strings.Cut(header, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token), false
+		}
+		return header, false
+	}
+	cookie, err := c.Cookie(auth.AccessCookie)
+	if err != nil {
+		return "", false
+	}
+	return cookie, true
+}
+
+// CheckToken checks the validity of the access token of the request and stores the principal in the context.
 //
-//	c *gin.Context
-func CheckTokenAdmin(c *gin.Context) {
-	tokenString := c.Request.Header.Get("Authorization")
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return st.Secret, nil
-	})
-	if err != nil || !token.Valid {
+// Requests without a valid token are aborted with 401, tokens of a revoked session are rejected
+// even if they have not expired yet. State-changing requests authenticated with the cookie
+// must carry the CSRF header matching the CSRF cookie, or they are aborted with 403.
+func (a *Authenticator) CheckToken(c *gin.Context) {
+	tokenString, fromCookie := accessToken(c)
+	if tokenString == "" {
+		c.Header("WWW-Authenticate", "Bearer")
+		abort(c, http.StatusUnauthorized, CodeTokenMissing, "Access token is missing")
+		return
+	}
+	if fromCookie && !auth.IsSafeMethod(c.Request.Method) && !auth.CheckCSRF(c.Request) {
+		abort(c, http.StatusForbidden, CodeCSRFFailed, "CSRF token is missing or wrong")
+		return
+	}
+	claims, err := a.keys.ParseAccessToken(tokenString)
+	if errors.Is(err, auth.ErrTokenExpired) {
+		abort(c, http.StatusUnauthorized, CodeTokenExpired, "Access token has expired")
+		return
+	}
+	if err != nil {
 		log.Println(err)
+		abort(c, http.StatusUnauthorized, CodeTokenInvalid, "Access token is invalid")
 		return
 	}
-	if role, ok := token.Claims.(jwt.MapClaims)["role"].(int); ok && role != 1 {
+	revoked, err := a.sessions.IsSessionRevoked(c.Request.Context(), claims.SessionID)
+	if err != nil {
 		log.Println(err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	if revoked {
+		log.Printf("session %s of %s is revoked\n", claims.SessionID, claims.Login)
+		abort(c, http.StatusUnauthorized, CodeSessionRevoked, "Session has ended, log in again")
 		return
 	}
-	c.Set("isAuthorizedAdmin", true)
+	c.Set(principalKey, Principal{Login: claims.Login, Role: claims.Role, SessionID: claims.SessionID})
 	c.Next()
 }
+
+// Authorizer checks the permissions of the authenticated user against the roles stored in the repository.
+type Authorizer struct {
+	roles repository.RoleRepository
+}
+
+// NewAuthorizer returns an Authorizer looking permissions up in the given repository.
+func NewAuthorizer(roles repository.RoleRepository) *Authorizer {
+	return &Authorizer{roles: roles}
+}
+
+// Require returns a middleware letting the request through only if the role of the principal
+// set by CheckToken grants the permission, otherwise it aborts with 403.
+//
+// The role is read from the database on every request, so a role change takes effect
+// immediately and the role claim of the token is never trusted.
+func (a *Authorizer) Require(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			abort(c, http.StatusUnauthorized, CodeTokenMissing, "Access token is missing")
+			return
+		}
+		ok, err := a.roles.HasPermission(c.Request.Context(), principal.Login, permission)
+		if err != nil {
+			log.Println(err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+			return
+		}
+		if !ok {
+			log.Printf("user %s lacks permission %s\n", principal.Login, permission)
+			abort(c, http.StatusForbidden, CodeForbidden, "Permission "+permission+" required")
+			return
+		}
+		c.Next()
+	}
+}
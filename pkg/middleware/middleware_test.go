@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	st "VK_app/internal/structures"
+	"VK_app/pkg/auth"
+	"VK_app/pkg/inmemory"
+	"VK_app/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// testEnv is a router behind the middleware along with what the tests sign tokens with.
+type testEnv struct {
+	router    *gin.Engine
+	keys      *auth.KeySet
+	publicPEM []byte
+}
+
+// newTestEnv returns a router serving GET /films to viewers and POST /films to editors.
+// The users viewer and editor have the roles of their names, the session of revoked has ended.
+// Tokens are signed with an RS256 key, an HS256 key is configured next to it
+// so that only the key id decides which algorithm a token may use.
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+	publicDER, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "rsa.pem")
+	if err := os.WriteFile(file, privatePEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := auth.LoadKeySet([]auth.KeyConfig{
+		{ID: "hs", Algorithm: auth.HS256, Secret: testSecret},
+		{ID: "rsa", Algorithm: auth.RS256, File: file},
+	}, "rsa")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := inmemory.New()
+	ctx := context.Background()
+	for _, user := range []st.User{{Login: "viewer", Role: "viewer"}, {Login: "editor", Role: "editor"}, {Login: "revoked", Role: "editor"}} {
+		if err := storage.AddUser(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		err := storage.CreateSession(ctx, st.Session{Id: "session-" + user.Login, Login: user.Login},
+			st.RefreshToken{Hash: "hash-" + user.Login, ExpiresAt: time.Now().Add(time.Hour)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := storage.RevokeSession(ctx, "session-revoked"); err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator := NewAuthenticator(keys, storage)
+	authorizer := NewAuthorizer(storage)
+	ok := func(c *gin.Context) {
+		principal, _ := GetPrincipal(c)
+		c.JSON(http.StatusOK, gin.H{"login": principal.Login})
+	}
+	router := gin.New()
+	group := router.Group("/", authenticator.CheckToken)
+	group.GET("/films", authorizer.Require(repository.PermFilmsRead), ok)
+	group.POST("/films", authorizer.Require(repository.PermCatalogWrite), ok)
+	return &testEnv{
+		router:    router,
+		keys:      keys,
+		publicPEM: pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}),
+	}
+}
+
+// token signs an access token of the user valid for an hour.
+func (e *testEnv) token(t *testing.T, login string) string {
+	t.Helper()
+	token, err := e.keys.NewAccessToken(login, login, "session-"+login, time.Now(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// forge signs the claims of the user with the method and key, setting the kid header.
+func forge(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, login string) string {
+	t.Helper()
+	now := time.Now()
+	token := jwt.NewWithClaims(method, auth.Claims{
+		Login:     login,
+		Role:      "admin",
+		SessionID: "session-" + login,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestMiddleware(t *testing.T) {
+	env := newTestEnv(t)
+	expired, err := env.keys.NewAccessToken("viewer", "viewer", "session-viewer", time.Now().Add(-2*time.Hour), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the role claim is never trusted, the stored role of the user decides
+	claimingAdmin, err := env.keys.NewAccessToken("viewer", "admin", "session-viewer", time.Now(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		cookie        string
+		want          int
+		code          string
+	}{
+		{"valid token", http.MethodGet, "Bearer " + env.token(t, "viewer"), "", http.StatusOK, ""},
+		{"bare token", http.MethodGet, env.token(t, "viewer"), "", http.StatusOK, ""},
+		{"missing token", http.MethodGet, "", "", http.StatusUnauthorized, CodeTokenMissing},
+		{"malformed token", http.MethodGet, "Bearer not.a.token", "", http.StatusUnauthorized, CodeTokenInvalid},
+		{"expired token", http.MethodGet, "Bearer " + expired, "", http.StatusUnauthorized, CodeTokenExpired},
+		{"HS256 token signed with the RSA public key", http.MethodGet,
+			"Bearer " + forge(t, jwt.SigningMethodHS256, "rsa", env.publicPEM, "viewer"), "", http.StatusUnauthorized, CodeTokenInvalid},
+		{"alg none token", http.MethodGet,
+			"Bearer " + forge(t, jwt.SigningMethodNone, "rsa", jwt.UnsafeAllowNoneSignatureType, "viewer"), "", http.StatusUnauthorized, CodeTokenInvalid},
+		{"token of a revoked session", http.MethodGet, "Bearer " + env.token(t, "revoked"), "", http.StatusUnauthorized, CodeSessionRevoked},
+		{"role without the permission", http.MethodPost, "Bearer " + env.token(t, "viewer"), "", http.StatusForbidden, CodeForbidden},
+		{"role claim of the token", http.MethodPost, "Bearer " + claimingAdmin, "", http.StatusForbidden, CodeForbidden},
+		{"role with the permission", http.MethodPost, "Bearer " + env.token(t, "editor"), "", http.StatusOK, ""},
+		{"cookie without the CSRF header", http.MethodPost, "", env.token(t, "editor"), http.StatusForbidden, CodeCSRFFailed},
+		{"cookie on a safe method", http.MethodGet, "", env.token(t, "viewer"), http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/films", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: auth.AccessCookie, Value: tt.cookie})
+			}
+			rec := httptest.NewRecorder()
+			env.router.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			var body map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if tt.code == "" {
+				return
+			}
+			if body["code"] != tt.code || body["error"] == "" {
+				t.Fatalf("body is %v, want code %q with an error message", body, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Package notify delivers messages to the users of the film library.
+package notify
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Notifier delivers messages to users.
+type Notifier interface {
+	// SendPasswordReset delivers the password reset token to the user.
+	SendPasswordReset(login, token string, expiresAt time.Time) error
+}
+
+// LogNotifier writes the messages to the application log, it is meant for local use.
+type LogNotifier struct{}
+
+// SendPasswordReset logs the password reset token.
+func (LogNotifier) SendPasswordReset(login, token string, expiresAt time.Time) error {
+	log.Printf("password reset token for %s: %s (expires at %s)\n", login, token, expiresAt.Format(time.RFC3339))
+	return nil
+}
+
+// FileNotifier appends the messages to a file, one per line, it is meant for local use.
+type FileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileNotifier returns a FileNotifier appending to the file at path.
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+// SendPasswordReset appends the password reset token to the file.
+func (n *FileNotifier) SendPasswordReset(login, token string, expiresAt time.Time) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(file, "%s password-reset login=%s token=%s expires=%s\n",
+		time.Now().Format(time.RFC3339), login, token, expiresAt.Format(time.RFC3339))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// New returns the notifier of the kind: "log" or "file", which appends to the file at path.
+func New(kind, path string) (Notifier, error) {
+	switch kind {
+	case "log":
+		return LogNotifier{}, nil
+	case "file":
+		return NewFileNotifier(path), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q, use log or file", kind)
+	}
+}
@@ -0,0 +1,95 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// GetActor returns the actor with the given id along with the credited films ordered by release date.
+//
+// It returns repository.ErrNotFound if there is no such actor.
+func (s *Storage) GetActor(ctx context.Context, id int) (structures.ActorResponse, error) {
+	var actor structures.ActorResponse
+	var fatherName sql.NullString
+	err := s.db.QueryRowContext(ctx, "SELECT id, name, surname, fathername, birthdate, sex FROM actors WHERE id = $1", id).
+		Scan(&actor.Id, &actor.Name, &actor.Surname, &fatherName, &actor.BirthDate, &actor.Sex)
+	if err != nil {
+		return actor, translateError(err)
+	}
+	actor.FatherName = fatherName.String
+	rows, err := s.db.QueryContext(ctx, "SELECT f.id, f.name, af.credit_type, coalesce(af.character, '') FROM films f JOIN actorsfilms af ON af.film_id = f.id WHERE af.actor_id=$1 ORDER BY f.date, f.id, af.credit_type", id)
+	if err != nil {
+		log.Println("problem with getting actor films", err)
+		return actor, err
+	}
+	defer rows.Close()
+	actor.Films = []structures.FilmResponse{}
+	for rows.Next() {
+		var film structures.FilmResponse
+		if err := rows.Scan(&film.Id, &film.Name, &film.CreditType, &film.Character); err != nil {
+			return actor, err
+		}
+		actor.Films = append(actor.Films, film)
+	}
+	return actor, rows.Err()
+}
+
+// listActorsQuery returns the page $2, $3 of the actors whose full name is like $1 along with
+// their credited films. The page is cut before joining the credits and is read in the order of
+// the actors_surname_name_idx index.
+const listActorsQuery = `SELECT a.id, a.name, a.surname, coalesce(a.fathername, ''), a.birthdate, a.sex,
+		coalesce(json_agg(json_build_object(
+			'id', f.id, 'name', f.name, 'credit_type', af.credit_type, 'character', af.character
+		) ORDER BY f.date, f.id, af.credit_type) FILTER (WHERE f.id IS NOT NULL), '[]')
+	FROM (
+		SELECT id, name, surname, fathername, birthdate, sex FROM actors
+		WHERE full_name ILIKE $1 ESCAPE '\'
+		ORDER BY surname, name, id
+		LIMIT $2 OFFSET $3
+	) a
+	LEFT JOIN actorsfilms af ON af.actor_id = a.id
+	LEFT JOIN films f ON f.id = af.film_id
+	GROUP BY a.id, a.name, a.surname, a.fathername, a.birthdate, a.sex
+	ORDER BY a.surname, a.name, a.id`
+
+// GetFilmsActor retrieves a page of actors along with their credited films.
+//
+// The films are aggregated with json_agg so the whole page is read with a single query.
+// Actors are ordered by surname, name and id, their films by release date.
+func (s *Storage) GetFilmsActor(ctx context.Context, q structures.ActorListQuery) (structures.ActorList, error) {
+	var list structures.ActorList
+	if err := repository.NormalizePage(&q.Limit, q.Offset); err != nil {
+		return list, err
+	}
+	name := containing(q.Name)
+	err := s.db.QueryRowContext(ctx, `SELECT count(*) FROM actors WHERE full_name ILIKE $1 ESCAPE '\'`, name).Scan(&list.Total)
+	if err != nil {
+		log.Println("problem with counting actors", err)
+		return list, err
+	}
+	rows, err := s.db.QueryContext(ctx, listActorsQuery, name, q.Limit, q.Offset)
+	if err != nil {
+		log.Println("problem with listing actors", err)
+		return list, err
+	}
+	defer rows.Close()
+	list.Actors = []structures.ActorResponse{}
+	for rows.Next() {
+		var actor structures.ActorResponse
+		var films []byte
+		err := rows.Scan(&actor.Id, &actor.Name, &actor.Surname, &actor.FatherName, &actor.BirthDate, &actor.Sex, &films)
+		if err != nil {
+			return list, err
+		}
+		if err := json.Unmarshal(films, &actor.Films); err != nil {
+			return list, err
+		}
+		list.Actors = append(list.Actors, actor)
+	}
+	return list, rows.Err()
+}
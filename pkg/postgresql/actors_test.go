@@ -0,0 +1,90 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+
+	"VK_app/internal/migrate"
+)
+
+func TestContaining(t *testing.T) {
+	tests := []struct {
+		piece string
+		want  string
+	}{
+		{"", "%%"},
+		{"Ривз", "%Ривз%"},
+		{"100%", `%100\%%`},
+		{"a_b", `%a\_b%`},
+		{`C:\films`, `%C:\\films%`},
+	}
+	for _, tt := range tests {
+		if got := containing(tt.piece); got != tt.want {
+			t.Errorf("containing(%q) = %q, want %q", tt.piece, got, tt.want)
+		}
+	}
+}
+
+// testDB returns the database of TEST_DATABASE_URL migrated up to date, the test is skipped without it.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// TestListActorsPlan checks that a page of the actor listing is read in index order
+// instead of sorting every actor. The actors are inserted in a transaction rolled back at the end.
+func TestListActorsPlan(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, `INSERT INTO actors (name, surname, birthdate, sex)
+		SELECT 'Имя' || i, 'Фамилия' || i % 5000, '19800101', 'm' FROM generate_series(1, 50000) i`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.ExecContext(ctx, "ANALYZE actors"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := tx.QueryContext(ctx, "EXPLAIN "+listActorsQuery, containing(""), 20, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var plan []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			t.Fatal(err)
+		}
+		plan = append(plan, line)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(strings.Join(plan, "\n"), "actors_surname_name_idx") {
+		t.Fatalf("the page is not read through actors_surname_name_idx:\n%s", strings.Join(plan, "\n"))
+	}
+}
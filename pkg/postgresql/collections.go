@@ -0,0 +1,234 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+
+	"github.com/lib/pq"
+)
+
+// collectionFields are the collections columns in the order scanCollection reads them.
+const collectionFields = `c.id, c.slug, c.login, c.name, coalesce(c.description, ''), c.public, c.created_at, c.updated_at,
+	(SELECT count(*) FROM collectionfilms cf WHERE cf.collection_id = c.id)`
+
+func scanCollection(row interface{ Scan(...interface{}) error }) (structures.Collection, error) {
+	var c structures.Collection
+	err := row.Scan(&c.Id, &c.Slug, &c.Login, &c.Name, &c.Description, &c.Public, &c.CreatedAt, &c.UpdatedAt, &c.FilmCount)
+	return c, err
+}
+
+// CreateCollection stores a new collection with no films.
+//
+// It returns repository.ErrInvalidCollection for empty or too long names and repository.ErrAlreadyExists if the slug is taken.
+func (s *Storage) CreateCollection(ctx context.Context, collection structures.Collection) error {
+	if err := repository.ValidateCollection(collection); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, "INSERT INTO collections (slug, login, name, description, public) VALUES ($1, $2, $3, $4, $5)",
+		collection.Slug, collection.Login, collection.Name, nullString(collection.Description), collection.Public)
+	if err != nil {
+		log.Println("problem with adding collection", err)
+		return translateError(err)
+	}
+	return nil
+}
+
+// GetCollection returns the collection along with its films in order.
+// Private collections are only returned to their owner.
+func (s *Storage) GetCollection(ctx context.Context, slug, viewer string) (structures.Collection, error) {
+	c, err := scanCollection(s.db.QueryRowContext(ctx, "SELECT "+collectionFields+" FROM collections c WHERE c.slug = $1 AND (c.public OR c.login = $2)", slug, viewer))
+	if err != nil {
+		return c, translateError(err)
+	}
+	c.Films, err = s.queryFilms(ctx, "SELECT "+joinedFilmFields+" FROM collectionfilms cf JOIN films f ON f.id = cf.film_id WHERE cf.collection_id = $1 ORDER BY cf.position", c.Id)
+	return c, err
+}
+
+// ListPublicCollections returns a page of public collections, the latest updated first.
+func (s *Storage) ListPublicCollections(ctx context.Context, q structures.CollectionListQuery) (structures.CollectionList, error) {
+	var b queryBuilder
+	b.conditions = append(b.conditions, "c.public")
+	if q.Query != "" {
+		b.conditions = append(b.conditions, "c.name ILIKE "+b.arg(containing(q.Query))+` ESCAPE '\'`)
+	}
+	if q.Login != "" {
+		b.conditions = append(b.conditions, "c.login = "+b.arg(q.Login))
+	}
+	return s.listCollections(ctx, b, q.Limit, q.Offset)
+}
+
+// GetUserCollections returns a page of the public and private collections of the user, the latest updated first.
+func (s *Storage) GetUserCollections(ctx context.Context, login string, limit, offset int) (structures.CollectionList, error) {
+	var b queryBuilder
+	b.conditions = append(b.conditions, "c.login = "+b.arg(login))
+	return s.listCollections(ctx, b, limit, offset)
+}
+
+func (s *Storage) listCollections(ctx context.Context, b queryBuilder, limit, offset int) (structures.CollectionList, error) {
+	var list structures.CollectionList
+	if err := repository.NormalizePage(&limit, offset); err != nil {
+		return list, err
+	}
+	err := s.db.QueryRowContext(ctx, "SELECT count(*) FROM collections c"+b.where(), b.args...).Scan(&list.Total)
+	if err != nil {
+		log.Println("problem with counting collections", err)
+		return list, err
+	}
+	rows, err := s.db.QueryContext(ctx, "SELECT "+collectionFields+" FROM collections c"+b.where()+
+		" ORDER BY c.updated_at DESC, c.id DESC LIMIT "+b.arg(limit)+" OFFSET "+b.arg(offset), b.args...)
+	if err != nil {
+		log.Println("problem with listing collections", err)
+		return list, err
+	}
+	defer rows.Close()
+	list.Collections = []structures.Collection{}
+	for rows.Next() {
+		c, err := scanCollection(rows)
+		if err != nil {
+			return list, err
+		}
+		list.Collections = append(list.Collections, c)
+	}
+	return list, rows.Err()
+}
+
+// UpdateCollection changes the non-nil fields of the collection of the user.
+func (s *Storage) UpdateCollection(ctx context.Context, login, slug string, update structures.CollectionUpdate) error {
+	if err := repository.ValidateCollectionUpdate(update); err != nil {
+		return err
+	}
+	var description interface{}
+	if update.Description != nil {
+		description = nullString(*update.Description)
+	}
+	res, err := s.db.ExecContext(ctx, `UPDATE collections SET
+		name = coalesce($3, name),
+		description = CASE WHEN $4 THEN $5 ELSE description END,
+		public = coalesce($6, public),
+		updated_at = now()
+		WHERE slug = $1 AND login = $2`,
+		slug, login, update.Name, update.Description != nil, description, update.Public)
+	if err != nil {
+		log.Println("problem with updating collection", err)
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// DelCollection deletes the collection of the user.
+func (s *Storage) DelCollection(ctx context.Context, login, slug string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM collections WHERE slug = $1 AND login = $2", slug, login)
+	if err != nil {
+		log.Println("problem with deleting collection", err)
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// AddCollectionFilm appends the film to the collection of the user.
+//
+// It returns repository.ErrNotFound if there is no such collection or film and repository.ErrAlreadyExists if the film is already there.
+func (s *Storage) AddCollectionFilm(ctx context.Context, login, slug string, filmID int) error {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		id, err := lockCollection(ctx, tx, login, slug)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO collectionfilms (collection_id, film_id, position)
+			SELECT $1, $2, coalesce(max(position), 0) + 1 FROM collectionfilms WHERE collection_id = $1`, id, filmID)
+		if err != nil {
+			return translateError(err)
+		}
+		return touchCollection(ctx, tx, id)
+	})
+	if err != nil {
+		log.Println("problem with adding film to collection", err)
+		return err
+	}
+	return nil
+}
+
+// DelCollectionFilm removes the film from the collection of the user.
+func (s *Storage) DelCollectionFilm(ctx context.Context, login, slug string, filmID int) error {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		id, err := lockCollection(ctx, tx, login, slug)
+		if err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, "DELETE FROM collectionfilms WHERE collection_id = $1 AND film_id = $2", id, filmID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return repository.ErrNotFound
+		}
+		return touchCollection(ctx, tx, id)
+	})
+	if err != nil {
+		log.Println("problem with removing film from collection", err)
+		return err
+	}
+	return nil
+}
+
+// ReorderCollection puts the films of the collection of the user in the given order.
+//
+// It returns repository.ErrInvalidCollection unless the order lists every film of the collection once.
+func (s *Storage) ReorderCollection(ctx context.Context, login, slug string, filmIDs []int) error {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		id, err := lockCollection(ctx, tx, login, slug)
+		if err != nil {
+			return err
+		}
+		var films []int64
+		err = tx.QueryRowContext(ctx, "SELECT coalesce(array_agg(film_id), '{}') FROM collectionfilms WHERE collection_id = $1", id).Scan(pq.Array(&films))
+		if err != nil {
+			return err
+		}
+		current := make([]int, len(films))
+		for i, film := range films {
+			current[i] = int(film)
+		}
+		if err := repository.ValidateOrder(current, filmIDs); err != nil {
+			return err
+		}
+		order := make([]int64, len(filmIDs))
+		for i, film := range filmIDs {
+			order[i] = int64(film)
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE collectionfilms cf SET position = o.position
+			FROM unnest($2::int[]) WITH ORDINALITY AS o(film_id, position)
+			WHERE cf.collection_id = $1 AND cf.film_id = o.film_id`, id, pq.Array(order))
+		if err != nil {
+			return err
+		}
+		return touchCollection(ctx, tx, id)
+	})
+	if err != nil {
+		log.Println("problem with reordering collection", err)
+		return err
+	}
+	return nil
+}
+
+// lockCollection returns the id of the collection of the user, locking it until the transaction ends.
+func lockCollection(ctx context.Context, tx *sql.Tx, login, slug string) (int, error) {
+	var id int
+	err := tx.QueryRowContext(ctx, "SELECT id FROM collections WHERE slug = $1 AND login = $2 FOR UPDATE", slug, login).Scan(&id)
+	return id, translateError(err)
+}
+
+func touchCollection(ctx context.Context, tx *sql.Tx, id int) error {
+	_, err := tx.ExecContext(ctx, "UPDATE collections SET updated_at = now() WHERE id = $1", id)
+	return err
+}
@@ -0,0 +1,106 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// nullString turns an empty string into SQL NULL.
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// nullInt turns a zero int into SQL NULL.
+func nullInt(i int) interface{} {
+	if i == 0 {
+		return nil
+	}
+	return i
+}
+
+// AddActorFilm credits an actor in a film.
+//
+// It returns repository.ErrInvalidCredit for unknown credit types and
+// repository.ErrAlreadyExists if the credit is already stored.
+func (s *Storage) AddActorFilm(ctx context.Context, actorFilm structures.ActorFilm) error {
+	if err := repository.NormalizeCredit(&actorFilm); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, "INSERT INTO actorsfilms (actor_id, film_id, credit_type, character, billing) VALUES ($1, $2, $3, $4, $5)",
+		actorFilm.ActorID, actorFilm.FilmID, actorFilm.CreditType, nullString(actorFilm.Character), nullInt(actorFilm.Billing))
+	if err != nil {
+		log.Println("problem with adding information about actor", err)
+		return translateError(err)
+	}
+	return nil
+}
+
+// UpdateActorFilm replaces the character and the billing position of a credit.
+//
+// It returns repository.ErrNotFound if there is no such credit.
+func (s *Storage) UpdateActorFilm(ctx context.Context, actorFilm structures.ActorFilm) error {
+	if err := repository.NormalizeCredit(&actorFilm); err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, "UPDATE actorsfilms SET character=$1, billing=$2 WHERE actor_id=$3 AND film_id=$4 AND credit_type=$5",
+		nullString(actorFilm.Character), nullInt(actorFilm.Billing), actorFilm.ActorID, actorFilm.FilmID, actorFilm.CreditType)
+	if err != nil {
+		log.Println("problem with updating information about credit", err)
+		return translateError(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// DelActorFilm removes a credit of an actor in a film.
+//
+// It returns repository.ErrNotFound if there is no such credit.
+func (s *Storage) DelActorFilm(ctx context.Context, actorFilm structures.ActorFilm) error {
+	if err := repository.NormalizeCredit(&actorFilm); err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, "DELETE FROM actorsfilms WHERE actor_id=$1 AND film_id=$2 AND credit_type=$3", actorFilm.ActorID, actorFilm.FilmID, actorFilm.CreditType)
+	if err != nil {
+		log.Println("problem with deleting information about credit", err)
+		return translateError(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// GetFilmCredits returns the cast and crew of the film ordered by credit type and billing.
+func (s *Storage) GetFilmCredits(ctx context.Context, filmID int) ([]structures.CreditResponse, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT a.id, a.name, a.surname, af.credit_type, af.character, af.billing
+		FROM actorsfilms af JOIN actors a ON a.id = af.actor_id
+		WHERE af.film_id = $1
+		ORDER BY af.credit_type, af.billing NULLS LAST, a.id`, filmID)
+	if err != nil {
+		log.Println("problem with getting film credits", err)
+		return nil, err
+	}
+	defer rows.Close()
+	credits := []structures.CreditResponse{}
+	for rows.Next() {
+		var credit structures.CreditResponse
+		var character sql.NullString
+		var billing sql.NullInt64
+		if err := rows.Scan(&credit.ActorID, &credit.Name, &credit.Surname, &credit.CreditType, &character, &billing); err != nil {
+			return nil, err
+		}
+		credit.Character = character.String
+		credit.Billing = int(billing.Int64)
+		credits = append(credits, credit)
+	}
+	return credits, rows.Err()
+}
@@ -0,0 +1,145 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// filmColumns maps the sort fields to the columns of the films table.
+var filmColumns = map[string]string{
+	"rating": "rating",
+	"name":   "name",
+	"date":   "date",
+}
+
+// queryBuilder collects the WHERE conditions and numbered arguments of a query.
+type queryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// arg appends the value to the arguments and returns its placeholder.
+func (b *queryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *queryBuilder) where() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// filmFilters adds the rating and date range conditions of the query.
+func (b *queryBuilder) filmFilters(q structures.FilmListQuery) {
+	if q.RatingFrom != nil {
+		b.conditions = append(b.conditions, "rating >= "+b.arg(*q.RatingFrom)+"::real")
+	}
+	if q.RatingTo != nil {
+		b.conditions = append(b.conditions, "rating <= "+b.arg(*q.RatingTo)+"::real")
+	}
+	if q.DateFrom != "" {
+		b.conditions = append(b.conditions, "date >= "+b.arg(q.DateFrom))
+	}
+	if q.DateTo != "" {
+		b.conditions = append(b.conditions, "date <= "+b.arg(q.DateTo))
+	}
+	if q.Genre != "" {
+		b.conditions = append(b.conditions, genreCondition(b.arg(q.Genre)))
+	}
+}
+
+// keyset adds the condition selecting the films placed after the cursor film.
+// The id is always the last, ascending, sort key so the order is total.
+func (b *queryBuilder) keyset(after structures.Film, sort []structures.SortKey) {
+	values := map[string]interface{}{
+		"rating": after.Rating,
+		"name":   after.Name,
+		"date":   after.Date,
+	}
+	var alternatives []string
+	var equal []string
+	for _, key := range sort {
+		column := filmColumns[key.Field]
+		placeholder := b.arg(values[key.Field])
+		if key.Field == "rating" {
+			placeholder += "::real"
+		}
+		op := ">"
+		if key.Order == "desc" {
+			op = "<"
+		}
+		alternatives = append(alternatives, "("+strings.Join(append(equal, column+" "+op+" "+placeholder), " AND ")+")")
+		equal = append(equal, column+" = "+placeholder)
+	}
+	alternatives = append(alternatives, "("+strings.Join(append(equal, "id > "+b.arg(after.Id)), " AND ")+")")
+	b.conditions = append(b.conditions, "("+strings.Join(alternatives, " OR ")+")")
+}
+
+// orderBy returns the ORDER BY clause for the sort keys.
+func orderBy(sort []structures.SortKey) string {
+	parts := make([]string, 0, len(sort)+1)
+	for _, key := range sort {
+		parts = append(parts, filmColumns[key.Field]+" "+strings.ToUpper(key.Order))
+	}
+	parts = append(parts, "id ASC")
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// ListFilms returns one page of films matching the query along with the total count.
+//
+// The page is selected either by offset or by the keyset cursor of the previous page.
+func (s *Storage) ListFilms(ctx context.Context, q structures.FilmListQuery) (structures.FilmList, error) {
+	var list structures.FilmList
+	if err := repository.NormalizeFilmListQuery(&q); err != nil {
+		return list, err
+	}
+
+	var b queryBuilder
+	b.filmFilters(q)
+	err := s.db.QueryRowContext(ctx, "SELECT count(*) FROM films"+b.where(), b.args...).Scan(&list.Total)
+	if err != nil {
+		log.Println("problem with counting films", err)
+		return list, err
+	}
+
+	if q.Cursor != "" {
+		after, err := repository.DecodeFilmCursor(q.Cursor, q.Sort)
+		if err != nil {
+			return list, err
+		}
+		b.keyset(after, q.Sort)
+	}
+	query := "SELECT " + filmFields + " FROM films" + b.where() + orderBy(q.Sort)
+	query += " LIMIT " + b.arg(q.Limit+1) + " OFFSET " + b.arg(q.Offset)
+	films, err := s.queryFilms(ctx, query, b.args...)
+	if err != nil {
+		return list, err
+	}
+	if len(films) > q.Limit {
+		films = films[:q.Limit]
+		list.NextCursor = repository.EncodeFilmCursor(films[len(films)-1], q.Sort)
+	}
+	list.Films = append([]structures.Film{}, films...)
+	return list, nil
+}
+
+// GetFilm returns the film with the given id along with its genres.
+//
+// It returns repository.ErrNotFound if there is no such film.
+func (s *Storage) GetFilm(ctx context.Context, id int) (structures.Film, error) {
+	films, err := s.queryFilms(ctx, "SELECT "+filmFields+" FROM films WHERE id = $1", id)
+	if err != nil {
+		return structures.Film{}, err
+	}
+	if len(films) == 0 {
+		return structures.Film{}, repository.ErrNotFound
+	}
+	return films[0], nil
+}
@@ -0,0 +1,139 @@
+package postgresql
+
+import (
+	"context"
+	"log"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+
+	"github.com/lib/pq"
+)
+
+// AddGenre adds a genre to the database.
+//
+// It returns repository.ErrAlreadyExists if a genre with the same name exists.
+func (s *Storage) AddGenre(ctx context.Context, genre structures.Genre) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO genres (name) VALUES ($1)", genre.Name)
+	if err != nil {
+		log.Println("problem with adding information about genre", err)
+		return translateError(err)
+	}
+	return nil
+}
+
+// UpdateGenre renames the genre with the given id.
+//
+// It returns repository.ErrNotFound if there is no such genre and repository.ErrAlreadyExists if the name is taken.
+func (s *Storage) UpdateGenre(ctx context.Context, genre structures.Genre) error {
+	if genre.Name == "" {
+		log.Println("no fields to update")
+		return nil
+	}
+	res, err := s.db.ExecContext(ctx, "UPDATE genres SET name=$1 WHERE id=$2", genre.Name, genre.Id)
+	if err != nil {
+		log.Println("problem with updating information about genre", err)
+		return translateError(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// DelGenre deletes the genre and, by cascade, its links to films.
+func (s *Storage) DelGenre(ctx context.Context, id int) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM genres WHERE id=$1", id)
+	if err != nil {
+		log.Println("problem with deleting information about genre", err)
+		return err
+	}
+	return nil
+}
+
+// CheckGenre checks the existence of a genre in the database.
+//
+// It returns repository.ErrNotFound if there is no such genre.
+func (s *Storage) CheckGenre(ctx context.Context, id int) error {
+	var name string
+	err := s.db.QueryRowContext(ctx, "SELECT name FROM genres WHERE id = $1", id).Scan(&name)
+	if err != nil {
+		log.Println("problem with checking information about genre", err)
+		return translateError(err)
+	}
+	return nil
+}
+
+// GetGenres returns all genres ordered by name.
+func (s *Storage) GetGenres(ctx context.Context) ([]structures.Genre, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name FROM genres ORDER BY name")
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+	genres := []structures.Genre{}
+	for rows.Next() {
+		var genre structures.Genre
+		if err := rows.Scan(&genre.Id, &genre.Name); err != nil {
+			return nil, err
+		}
+		genres = append(genres, genre)
+	}
+	return genres, rows.Err()
+}
+
+// AddFilmGenre links a film to a genre.
+//
+// It returns repository.ErrAlreadyExists if the link is already stored.
+func (s *Storage) AddFilmGenre(ctx context.Context, filmGenre structures.FilmGenre) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO filmsgenres (film_id, genre_id) VALUES ($1, $2)", filmGenre.FilmID, filmGenre.GenreID)
+	if err != nil {
+		log.Println("problem with adding information about film genre", err)
+		return translateError(err)
+	}
+	return nil
+}
+
+// DelFilmGenre removes the link between a film and a genre.
+func (s *Storage) DelFilmGenre(ctx context.Context, filmGenre structures.FilmGenre) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM filmsgenres WHERE film_id=$1 AND genre_id=$2", filmGenre.FilmID, filmGenre.GenreID)
+	if err != nil {
+		log.Println("problem with deleting information about film genre", err)
+		return err
+	}
+	return nil
+}
+
+// attachGenres fills in the genre names of the films with a single query.
+func (s *Storage) attachGenres(ctx context.Context, films []structures.Film) error {
+	if len(films) == 0 {
+		return nil
+	}
+	index := make(map[int]int, len(films))
+	ids := make([]int64, 0, len(films))
+	for i, film := range films {
+		index[film.Id] = i
+		ids = append(ids, int64(film.Id))
+	}
+	rows, err := s.db.QueryContext(ctx, "SELECT fg.film_id, g.name FROM filmsgenres fg JOIN genres g ON g.id = fg.genre_id WHERE fg.film_id = ANY($1) ORDER BY g.name", pq.Array(ids))
+	if err != nil {
+		log.Println("problem with getting film genres", err)
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var filmID int
+		var name string
+		if err := rows.Scan(&filmID, &name); err != nil {
+			return err
+		}
+		films[index[filmID]].Genres = append(films[index[filmID]].Genres, name)
+	}
+	return rows.Err()
+}
+
+// genreCondition is the condition restricting films to the genre bound to the placeholder.
+func genreCondition(placeholder string) string {
+	return "id IN (SELECT fg.film_id FROM filmsgenres fg JOIN genres g ON g.id = fg.genre_id WHERE lower(g.name) = lower(" + placeholder + "))"
+}
@@ -0,0 +1,61 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"VK_app/pkg/repository"
+)
+
+// CreatePasswordReset stores a reset token of the user, replacing the earlier unused ones.
+//
+// It returns repository.ErrNotFound if there is no such user.
+func (s *Storage) CreatePasswordReset(ctx context.Context, hash, login string, expiresAt time.Time) error {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM passwordresets WHERE login = $1 AND used_at IS NULL", login); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "INSERT INTO passwordresets (token_hash, login, expires_at) VALUES ($1, $2, $3)", hash, login, expiresAt)
+		return translateError(err)
+	})
+	if err != nil {
+		log.Println("problem with creating password reset", err)
+		return err
+	}
+	return nil
+}
+
+// GetPasswordResetLogin returns the login of the user of a reset token that can still be used.
+//
+// It returns repository.ErrNotFound if the token is unknown, expired or already used.
+func (s *Storage) GetPasswordResetLogin(ctx context.Context, hash string) (string, error) {
+	var login string
+	err := s.db.QueryRowContext(ctx, "SELECT login FROM passwordresets WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()", hash).Scan(&login)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Println("problem with getting password reset", err)
+	}
+	return login, translateError(err)
+}
+
+// ResetPassword uses up the reset token and replaces the password hash of its user.
+//
+// It returns repository.ErrNotFound if the token is unknown, expired or already used.
+func (s *Storage) ResetPassword(ctx context.Context, hash, password string) (string, error) {
+	var login string
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `UPDATE passwordresets SET used_at = now()
+			WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now() RETURNING login`, hash).Scan(&login)
+		if err != nil {
+			return translateError(err)
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE users SET password=$1 WHERE login=$2", password, login)
+		return err
+	})
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		log.Println("problem with resetting password", err)
+	}
+	return login, err
+}
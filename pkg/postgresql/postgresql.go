@@ -1,208 +1,185 @@
 package postgresql
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 
 	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
 
-	l "VK_app/internal/dbconn"
+	"github.com/lib/pq"
+)
+
+// Storage is the PostgreSQL implementation of the film, actor and user repositories.
+// Queries run under the context of their operation and are cancelled along with it.
+type Storage struct {
+	db *sql.DB
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns, the queries match them with ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containing returns the LIKE pattern matching the strings that contain s literally.
+func containing(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+// New returns a Storage working on top of the given database connection.
+func New(db *sql.DB) *Storage {
+	return &Storage{db: db}
+}
 
-	_ "github.com/lib/pq"
-	"golang.org/x/crypto/bcrypt"
+var (
+	_ repository.FilmRepository           = (*Storage)(nil)
+	_ repository.ActorRepository          = (*Storage)(nil)
+	_ repository.GenreRepository          = (*Storage)(nil)
+	_ repository.ReviewRepository         = (*Storage)(nil)
+	_ repository.RoleRepository           = (*Storage)(nil)
+	_ repository.SessionRepository        = (*Storage)(nil)
+	_ repository.PasswordResetRepository  = (*Storage)(nil)
+	_ repository.UserRepository           = (*Storage)(nil)
+	_ repository.WatchlistRepository      = (*Storage)(nil)
+	_ repository.CollectionRepository     = (*Storage)(nil)
+	_ repository.RecommendationRepository = (*Storage)(nil)
 )
 
-// AddUser adds a new user to the database.
-//
-// It takes a pointer to a structures.User struct as a parameter.
-// It returns an error if there was an issue adding the user.
+// PostgreSQL error codes of constraint violations.
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
 
-func AddUser(u *structures.User) error {
-	hashedpassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), 12)
-	if err != nil {
-		log.Printf("addUser: %v\n", err)
-		return err
-	}
-	u.Password = string(hashedpassword) //hashedpassword
-	fmt.Println(u.Password)
-	_, err = l.Db.Exec("INSERT INTO users (login,password,role) VALUES ($1, $2, $3)", u.Login, u.Password, u.Role)
-	if err != nil {
-		log.Printf("addUser: %v\n", err)
-		return err
+// translateError maps driver errors to the repository sentinel errors.
+func translateError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case uniqueViolation:
+			return repository.ErrAlreadyExists
+		case foreignKeyViolation:
+			return repository.ErrNotFound
+		}
 	}
-	log.Println("user was appended successfully")
-	return nil
+	return err
 }
 
-// GetFilmsSortedRating returns a slice of structures.Film sorted by rating in descending order.
-//
-// No parameters.
-// Returns a slice of structures.Film.
-func GetFilmsSortedRating() []structures.Film {
-	var films []structures.Film
-	rows, err := l.Db.Query("SELECT * FROM films ORDER BY rating DESC")
+// filmFields are the films columns in the order scanFilms reads them.
+const filmFields = "id, name, description, date, rating, votes"
+
+// queryFilms runs a query selecting filmFields and returns the films along with their genres.
+func (s *Storage) queryFilms(ctx context.Context, query string, args ...interface{}) ([]structures.Film, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		log.Println(err)
+		return nil, err
 	}
-	defer rows.Close()
+	films, err := scanFilms(rows)
+	if err != nil {
+		return nil, err
+	}
+	return films, s.attachGenres(ctx, films)
+}
 
+// scanFilms reads all the rows of a films query.
+func scanFilms(rows *sql.Rows) ([]structures.Film, error) {
+	defer rows.Close()
+	var films []structures.Film
 	for rows.Next() {
 		film := structures.Film{}
-		err := rows.Scan(&film.Id, &film.Name, &film.Description, &film.Date, &film.Rating)
+		err := rows.Scan(&film.Id, &film.Name, &film.Description, &film.Date, &film.Rating, &film.Votes)
 		if err != nil {
-			log.Println(err)
-			continue
+			return nil, err
 		}
 		films = append(films, film)
 	}
-	return films
+	return films, rows.Err()
 }
 
-// GetFilmsSortedDate retrieves and returns films sorted by date.
+// AddUser adds a new user to the database.
 //
-// No parameters.
-// Returns a slice of structures.Film.
-func GetFilmsSortedDate() []structures.Film {
-	var films []structures.Film
-	rows, err := l.Db.Query("SELECT * FROM films ORDER BY date DESC")
+// It takes a structures.User with an already hashed password as a parameter.
+// It returns repository.ErrAlreadyExists if the login is taken.
+func (s *Storage) AddUser(ctx context.Context, u structures.User) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO users (login,password,role) VALUES ($1, $2, $3)", u.Login, u.Password, u.Role)
 	if err != nil {
-		log.Println(err)
+		log.Printf("addUser: %v\n", err)
+		return translateError(err)
 	}
-	defer rows.Close()
-	for rows.Next() {
-		film := structures.Film{}
-		err := rows.Scan(&film.Id, &film.Name, &film.Description, &film.Date, &film.Rating)
-		if err != nil {
-			log.Println(err)
-			continue
-		}
-		films = append(films, film)
+	log.Println("user was appended successfully")
+	return nil
+}
+
+// GetUser returns the user with the given login.
+//
+// It returns repository.ErrNotFound if there is no such user.
+func (s *Storage) GetUser(ctx context.Context, login string) (structures.User, error) {
+	var user structures.User
+	err := s.db.QueryRowContext(ctx, "SELECT login, password, role, disabled FROM users WHERE login = $1", login).
+		Scan(&user.Login, &user.Password, &user.Role, &user.Disabled)
+	if err != nil {
+		return user, translateError(err)
 	}
-	return films
+	return user, nil
 }
 
-// GetFilmsSortedName retrieves and returns films sorted by name in descending order.
+// UpdatePassword replaces the password hash of the user.
 //
-// No parameters.
-// Returns a slice of structures.Film.
-func GetFilmsSortedName() []structures.Film {
-	var films []structures.Film
-	rows, err := l.Db.Query("SELECT * FROM films ORDER BY name DESC")
+// It returns repository.ErrNotFound if there is no such user.
+func (s *Storage) UpdatePassword(ctx context.Context, login, password string) error {
+	res, err := s.db.ExecContext(ctx, "UPDATE users SET password=$1 WHERE login=$2", password, login)
 	if err != nil {
-		log.Println(err)
+		log.Println("problem with updating password", err)
+		return err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		film := structures.Film{}
-		err := rows.Scan(&film.Id, &film.Name, &film.Description, &film.Date, &film.Rating)
-		if err != nil {
-			log.Println(err)
-			continue
-		}
-		films = append(films, film)
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return repository.ErrNotFound
 	}
-	return films
+	return nil
 }
 
-// GetFilmsPieceActor retrieves films related to a specific actor piece.
+// GetFilmsPieceActor retrieves films featuring an actor whose full name contains the piece, ignoring case.
 //
 // Parameter:
 // piece string - the actor piece to search for in the database.
+// genre string - the genre the films must belong to, ignored if empty.
 // []structures.Film - a slice of structures.Film containing the retrieved films.
-func GetFilmsPieceActor(piece string) []structures.Film {
-	var films []structures.Film
-	rows, err := l.Db.Query("SELECT * FROM films WHERE id IN (SELECT film_id FROM actorsfilms WHERE actor_id IN (SELECT id FROM actors WHERE name LIKE $1))", "%"+piece+"%")
-	if err != nil {
-		log.Println(err)
-	}
-	for rows.Next() {
-		film := structures.Film{}
-		err := rows.Scan(&film.Id, &film.Name, &film.Description, &film.Date, &film.Rating)
-		if err != nil {
-			log.Println(err)
-			continue
-		}
-		films = append(films, film)
-	}
-	return films
+func (s *Storage) GetFilmsPieceActor(ctx context.Context, piece, genre string) ([]structures.Film, error) {
+	query := "SELECT " + filmFields + " FROM films WHERE id IN (SELECT film_id FROM actorsfilms WHERE credit_type = 'actor' AND actor_id IN (SELECT id FROM actors WHERE full_name ILIKE $1 ESCAPE '\\'))"
+	args := []interface{}{containing(piece)}
+	if genre != "" {
+		query += " AND " + genreCondition("$2")
+		args = append(args, genre)
+	}
+	return s.queryFilms(ctx, query, args...)
 }
 
-// GetFilmsPieceFilm retrieves films containing a specific piece in their name.
+// GetFilmsPieceFilm retrieves films containing a specific piece in their name, ignoring case.
 //
 // piece - the string to search for in film names.
+// genre - the genre the films must belong to, ignored if empty.
 // []structures.Film - a slice of Film structures containing the matching films.
-func GetFilmsPieceFilm(piece string) []structures.Film {
-	var films []structures.Film
-	rows, err := l.Db.Query("SELECT * FROM films WHERE name LIKE $1", "%"+piece+"%")
-	if err != nil {
-		log.Println(err)
-	}
-	for rows.Next() {
-		film := structures.Film{}
-		err := rows.Scan(&film.Id, &film.Name, &film.Description, &film.Date, &film.Rating)
-		if err != nil {
-			log.Println(err)
-			continue
-		}
-		films = append(films, film)
-	}
-	return films
-}
-
-// GetFilmsActor retrieves a list of actors along with their films from the database.
-//
-// No parameters.
-// Returns a slice of structures.ActorResponse.
-func GetFilmsActor() []structures.ActorResponse {
-	var actors = map[int]structures.ActorResponse{}
-	var actorsResponse []structures.ActorResponse
-	var idsactors []int
-	rows, err := l.Db.Query("SELECT * FROM actors")
-	if err != nil {
-		log.Println(err)
-		return actorsResponse
-	}
-	for rows.Next() {
-		actor := structures.ActorResponse{}
-		err := rows.Scan(&actor.Id, &actor.Name, &actor.Surname, &actor.FatherName, &actor.BirthDate, &actor.Sex)
-		if err != nil {
-			log.Println(err)
-			continue
-		}
-		actors[actor.Id] = actor
-		idsactors = append(idsactors, actor.Id)
-	}
-	for _, id := range idsactors {
-		var films []structures.FilmResponse
-		rows, err = l.Db.Query("SELECT id, name FROM films WHERE id IN (SELECT film_id FROM actorsfilms WHERE actor_id=$1)", id)
-		if err != nil {
-			log.Println(err)
-			return actorsResponse
-		}
-		for rows.Next() {
-			film := structures.FilmResponse{}
-			err := rows.Scan(&film.Id, &film.Name)
-			if err != nil {
-				log.Println(err)
-				continue
-			}
-			films = append(films, film)
-		}
-		actor := actors[id]
-		actor.Films = films
-		actors[id] = actor
-	}
-	for _, value := range actors {
-		actorsResponse = append(actorsResponse, value)
-	}
-	return actorsResponse
+func (s *Storage) GetFilmsPieceFilm(ctx context.Context, piece, genre string) ([]structures.Film, error) {
+	query := "SELECT " + filmFields + " FROM films WHERE name ILIKE $1 ESCAPE '\\'"
+	args := []interface{}{containing(piece)}
+	if genre != "" {
+		query += " AND " + genreCondition("$2")
+		args = append(args, genre)
+	}
+	return s.queryFilms(ctx, query, args...)
 }
 
 // DelActor deletes information about an actor from the database.
 //
 // It takes an integer parameter 'id' and returns an error.
-func DelActor(id int) error {
-	_, err := l.Db.Exec("DELETE FROM actors WHERE id=$1", id)
+func (s *Storage) DelActor(ctx context.Context, id int) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM actors WHERE id=$1", id)
 	if err != nil {
 		log.Println("problem with deleting information about actor", err)
 		return err
@@ -219,20 +196,21 @@ func DelActor(id int) error {
 // Return type(s):
 //
 //	error - an error, if any.
-func DelFilm(id int) error {
-	_, err := l.Db.Exec("DELETE FROM films WHERE id=$1", id)
+func (s *Storage) DelFilm(ctx context.Context, id int) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM films WHERE id=$1", id)
 	if err != nil {
-		log.Println("problem with deleting information about actor", err)
+		log.Println("problem with deleting information about film", err)
 		return err
 	}
 	return nil
 }
 
 // UpdateFilm updates film information in the database.
+// The rating is computed from the reviews and can not be updated.
 //
 // Takes a structures.Film object as input.
 // Returns an error.
-func UpdateFilm(film structures.Film) error {
+func (s *Storage) UpdateFilm(ctx context.Context, film structures.Film) error {
 	args := []interface{}{}
 	counter := 1
 	query := "UPDATE films SET"
@@ -251,11 +229,6 @@ func UpdateFilm(film structures.Film) error {
 		args = append(args, film.Date)
 		counter++
 	}
-	if film.Rating != 0 {
-		query += fmt.Sprintf(" rating=$%d,", counter)
-		args = append(args, film.Rating)
-		counter++
-	}
 	if counter == 1 {
 		log.Println("no fields to update")
 		return nil
@@ -264,7 +237,7 @@ func UpdateFilm(film structures.Film) error {
 	query = query[:len(query)-1]
 	query += fmt.Sprintf(" WHERE id=$%d", counter)
 	args = append(args, film.Id)
-	_, err := l.Db.Exec(query, args...)
+	_, err := s.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		log.Println("problem with updating information about film", err)
 		return err
@@ -278,7 +251,7 @@ func UpdateFilm(film structures.Film) error {
 // It should have at least one field set to update the corresponding record in the database.
 //
 // The function returns an error if there was a problem with updating the actor information.
-func UpdateActor(actor structures.Actor) error {
+func (s *Storage) UpdateActor(ctx context.Context, actor structures.Actor) error {
 	args := []interface{}{}
 	counter := 1
 	query := "UPDATE actors SET"
@@ -314,7 +287,7 @@ func UpdateActor(actor structures.Actor) error {
 	query = query[:len(query)-1]
 	query += fmt.Sprintf(" WHERE id=$%d", counter)
 	args = append(args, actor.Id)
-	_, err := l.Db.Exec(query, args...)
+	_, err := s.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		log.Println("problem with updating information about actor", err)
 		return err
@@ -325,8 +298,8 @@ func UpdateActor(actor structures.Actor) error {
 // AddActor adds an actor to the database.
 //
 // It takes a structures.Actor as a parameter and returns an error.
-func AddActor(actor structures.Actor) error {
-	_, err := l.Db.Exec("INSERT INTO actors (name, surname, fathername, birthdate, sex) VALUES ($1, $2, $3, $4, $5)", actor.Name, actor.Surname, actor.FatherName, actor.BirthDate, actor.Sex)
+func (s *Storage) AddActor(ctx context.Context, actor structures.Actor) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO actors (name, surname, fathername, birthdate, sex) VALUES ($1, $2, $3, $4, $5)", actor.Name, actor.Surname, nullString(actor.FatherName), actor.BirthDate, actor.Sex)
 	if err != nil {
 		log.Println("problem with adding information about actor", err)
 		return err
@@ -336,46 +309,36 @@ func AddActor(actor structures.Actor) error {
 
 // CheckActor checks the existence of an actor in the database.
 //
-// It takes a structures.Actor as a parameter and returns an error.
-func CheckActor(id int) error {
+// It takes the actor id as a parameter and returns repository.ErrNotFound if there is no such actor.
+func (s *Storage) CheckActor(ctx context.Context, id int) error {
 	var name string
-	err := l.Db.QueryRow("SELECT name FROM actors WHERE id = $1", id).Scan(&name)
+	err := s.db.QueryRowContext(ctx, "SELECT name FROM actors WHERE id = $1", id).Scan(&name)
 	if err != nil {
 		log.Println("problem with checking information about actor", err)
-		return err
+		return translateError(err)
 	}
 	return nil
 }
 
-// CheckFilm checks theexistsence of a film in the database.
+// CheckFilm checks the existence of a film in the database.
 //
-// Parameter: film of type structures.Film.
-// Returns an error.
-func CheckFilm(id int) error {
+// It takes the film id as a parameter and returns repository.ErrNotFound if there is no such film.
+func (s *Storage) CheckFilm(ctx context.Context, id int) error {
 	var name string
-	err := l.Db.QueryRow("SELECT name FROM films WHERE id = $1", id).Scan(&name)
+	err := s.db.QueryRowContext(ctx, "SELECT name FROM films WHERE id = $1", id).Scan(&name)
 	if err != nil {
 		log.Println("problem with checking information about film", err)
-		return err
-	}
-	return nil
-}
-
-func AddActorFilm(actorFilm structures.ActorFilm) error {
-	_, err := l.Db.Exec("INSERT INTO actorsfilms (actor_id, film_id) VALUES ($1, $2)", actorFilm.ActorID, actorFilm.FilmID)
-	if err != nil {
-		log.Println("problem with adding information about actor", err)
-		return err
+		return translateError(err)
 	}
 	return nil
 }
 
-// AddFilm adds a film to the database.
+// AddFilm adds a film to the database, it starts with no rating.
 //
 // Parameter: film structures.Film
 // Return type: error
-func AddFilm(film structures.Film) error {
-	_, err := l.Db.Exec("INSERT INTO films (name, description, date, rating) VALUES ($1, $2, $3, $4)", film.Name, film.Description, film.Date, film.Rating)
+func (s *Storage) AddFilm(ctx context.Context, film structures.Film) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO films (name, description, date) VALUES ($1, $2, $3)", film.Name, film.Description, film.Date)
 	if err != nil {
 		log.Println("problem with adding information about film", err)
 		return err
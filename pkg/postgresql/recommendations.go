@@ -0,0 +1,96 @@
+package postgresql
+
+import (
+	"context"
+	"log"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/recommend"
+
+	"github.com/lib/pq"
+)
+
+// relatedFilmsQuery selects the given films and the best scoring films sharing credited people or genres
+// with them. Every shared credit or genre adds its weight times the weight of the given film.
+const relatedFilmsQuery = "SELECT " + filmFields + ` FROM films WHERE id = ANY($1) OR id IN (
+		WITH seeds AS (SELECT * FROM unnest($1::int8[], $2::float8[]) AS s(id, weight)),
+		shared AS (
+			SELECT other.film_id, s.weight * CASE af.credit_type
+					WHEN 'actor' THEN $4::float8 WHEN 'director' THEN $5::float8 ELSE $6::float8 END AS score
+			FROM seeds s JOIN actorsfilms af ON af.film_id = s.id
+			JOIN actorsfilms other ON other.actor_id = af.actor_id AND other.credit_type = af.credit_type
+			UNION ALL
+			SELECT other.film_id, s.weight * $7::float8
+			FROM seeds s JOIN filmsgenres fg ON fg.film_id = s.id
+			JOIN filmsgenres other ON other.genre_id = fg.genre_id
+		)
+		SELECT f.id FROM shared JOIN films f ON f.id = shared.film_id
+		WHERE f.id <> ALL($1)
+		GROUP BY f.id
+		HAVING sum(shared.score) > 0
+		ORDER BY sum(shared.score) DESC, f.rating DESC, f.id
+		LIMIT $3
+	)
+	ORDER BY id`
+
+// GetRelatedFilms returns the features of the given films and of at most limit other films
+// sharing the most with them, ordered by id. weights[i] is the weight of filmIDs[i].
+func (s *Storage) GetRelatedFilms(ctx context.Context, filmIDs []int, weights []float64, limit int) ([]structures.FilmFeatures, error) {
+	ids := make([]int64, len(filmIDs))
+	for i, id := range filmIDs {
+		ids[i] = int64(id)
+	}
+	films, err := s.queryFilms(ctx, relatedFilmsQuery, pq.Array(ids), pq.Array(weights), limit,
+		recommend.ActorWeight, recommend.DirectorWeight, recommend.CrewWeight, recommend.GenreWeight)
+	if err != nil {
+		return nil, err
+	}
+	features := make([]structures.FilmFeatures, len(films))
+	index := make(map[int]int, len(films))
+	related := make([]int64, len(films))
+	for i, film := range films {
+		features[i].Film = film
+		index[film.Id] = i
+		related[i] = int64(film.Id)
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT af.film_id, a.id, a.full_name, af.credit_type
+		FROM actorsfilms af JOIN actors a ON a.id = af.actor_id
+		WHERE af.film_id = ANY($1) ORDER BY af.film_id, af.credit_type, af.billing NULLS LAST, a.id`, pq.Array(related))
+	if err != nil {
+		log.Println("problem with getting credits of related films", err)
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var filmID int
+		var person structures.CreditedPerson
+		if err := rows.Scan(&filmID, &person.ActorID, &person.FullName, &person.CreditType); err != nil {
+			return nil, err
+		}
+		features[index[filmID]].People = append(features[index[filmID]].People, person)
+	}
+	return features, rows.Err()
+}
+
+// GetUserSignals returns the films the user rated or watched, ordered by film id.
+func (s *Storage) GetUserSignals(ctx context.Context, login string) ([]structures.UserSignal, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT film_id, max(score), bool_or(watched) FROM (
+			SELECT film_id, score, false AS watched FROM reviews WHERE login = $1
+			UNION ALL
+			SELECT film_id, 0, true FROM watched WHERE login = $1
+		) signals GROUP BY film_id ORDER BY film_id`, login)
+	if err != nil {
+		log.Println("problem with getting user signals", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var signals []structures.UserSignal
+	for rows.Next() {
+		var signal structures.UserSignal
+		if err := rows.Scan(&signal.FilmID, &signal.Score, &signal.Watched); err != nil {
+			return nil, err
+		}
+		signals = append(signals, signal)
+	}
+	return signals, rows.Err()
+}
@@ -0,0 +1,143 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// updateRatingQuery recomputes the rating and the votes of the film $1 from its reviews.
+// The film must be locked with lockFilm first, or a concurrent change of its reviews
+// committed in between would be left out of the aggregate.
+const updateRatingQuery = `UPDATE films SET
+	rating = coalesce((SELECT avg(score) FROM reviews WHERE film_id = $1), 0),
+	votes = (SELECT count(*) FROM reviews WHERE film_id = $1)
+	WHERE id = $1`
+
+// inTx runs fn in a transaction and commits it if fn succeeds.
+func (s *Storage) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockFilm locks the film until the transaction ends, serializing the changes of its reviews.
+func lockFilm(ctx context.Context, tx *sql.Tx, filmID int) error {
+	var id int
+	err := tx.QueryRowContext(ctx, "SELECT id FROM films WHERE id = $1 FOR UPDATE", filmID).Scan(&id)
+	return translateError(err)
+}
+
+// UpsertReview creates the review of the user for the film or replaces its score and text,
+// then recomputes the film rating.
+//
+// It returns repository.ErrInvalidReview for scores out of range and repository.ErrNotFound if there is no such film.
+func (s *Storage) UpsertReview(ctx context.Context, review structures.Review) error {
+	if err := repository.ValidateReview(review); err != nil {
+		return err
+	}
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := lockFilm(ctx, tx, review.FilmID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO reviews (film_id, login, score, text) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (film_id, login) DO UPDATE SET score = EXCLUDED.score, text = EXCLUDED.text, updated_at = now()`,
+			review.FilmID, review.Login, review.Score, nullString(review.Text))
+		if err != nil {
+			return translateError(err)
+		}
+		_, err = tx.ExecContext(ctx, updateRatingQuery, review.FilmID)
+		return err
+	})
+	if err != nil {
+		log.Println("problem with saving review", err)
+		return err
+	}
+	return nil
+}
+
+// GetFilmReviews returns a page of the film reviews, the newest first, along with their total count.
+func (s *Storage) GetFilmReviews(ctx context.Context, filmID, limit, offset int) (structures.ReviewList, error) {
+	var list structures.ReviewList
+	if err := repository.NormalizePage(&limit, offset); err != nil {
+		return list, err
+	}
+	err := s.db.QueryRowContext(ctx, "SELECT count(*) FROM reviews WHERE film_id = $1", filmID).Scan(&list.Total)
+	if err != nil {
+		log.Println("problem with counting reviews", err)
+		return list, err
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT id, film_id, login, score, coalesce(text, ''), created_at, updated_at
+		FROM reviews WHERE film_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3`, filmID, limit, offset)
+	if err != nil {
+		log.Println("problem with getting reviews", err)
+		return list, err
+	}
+	defer rows.Close()
+	list.Reviews = []structures.Review{}
+	for rows.Next() {
+		var review structures.Review
+		err := rows.Scan(&review.Id, &review.FilmID, &review.Login, &review.Score, &review.Text, &review.CreatedAt, &review.UpdatedAt)
+		if err != nil {
+			return list, err
+		}
+		list.Reviews = append(list.Reviews, review)
+	}
+	return list, rows.Err()
+}
+
+// DelReview deletes the review with the given id and recomputes the film rating.
+func (s *Storage) DelReview(ctx context.Context, id int) error {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		// the film of a review never changes, so it can be read before the film is locked
+		var filmID int
+		if err := tx.QueryRowContext(ctx, "SELECT film_id FROM reviews WHERE id = $1", id).Scan(&filmID); err != nil {
+			return translateError(err)
+		}
+		return delReview(ctx, tx, filmID, "DELETE FROM reviews WHERE id = $1", id)
+	})
+	if err != nil {
+		log.Println("problem with deleting review", err)
+		return err
+	}
+	return nil
+}
+
+// DelUserReview deletes the review of the user for the film and recomputes the film rating.
+func (s *Storage) DelUserReview(ctx context.Context, filmID int, login string) error {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		return delReview(ctx, tx, filmID, "DELETE FROM reviews WHERE film_id = $1 AND login = $2", filmID, login)
+	})
+	if err != nil {
+		log.Println("problem with deleting review", err)
+		return err
+	}
+	return nil
+}
+
+// delReview locks the film, runs the delete query and recomputes the film rating.
+func delReview(ctx context.Context, tx *sql.Tx, filmID int, query string, args ...interface{}) error {
+	if err := lockFilm(ctx, tx, filmID); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repository.ErrNotFound
+	}
+	_, err = tx.ExecContext(ctx, updateRatingQuery, filmID)
+	return err
+}
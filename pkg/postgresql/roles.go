@@ -0,0 +1,59 @@
+package postgresql
+
+import (
+	"context"
+	"log"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+
+	"github.com/lib/pq"
+)
+
+// GetRoles returns every role with its permissions, ordered by role name.
+func (s *Storage) GetRoles(ctx context.Context) ([]structures.Role, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT r.name, coalesce(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+		FROM roles r LEFT JOIN rolepermissions rp ON rp.role = r.name
+		GROUP BY r.name ORDER BY r.name`)
+	if err != nil {
+		log.Println("problem with getting roles", err)
+		return nil, err
+	}
+	defer rows.Close()
+	roles := []structures.Role{}
+	for rows.Next() {
+		var role structures.Role
+		if err := rows.Scan(&role.Name, pq.Array(&role.Permissions)); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// HasPermission reports whether the role currently stored for the user grants the permission.
+func (s *Storage) HasPermission(ctx context.Context, login, permission string) (bool, error) {
+	var ok bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users u JOIN rolepermissions rp ON rp.role = u.role
+		WHERE u.login = $1 AND rp.permission = $2)`, login, permission).Scan(&ok)
+	if err != nil {
+		log.Println("problem with checking permission", err)
+		return false, err
+	}
+	return ok, nil
+}
+
+// SetUserRole assigns the role to the user.
+//
+// It returns repository.ErrNotFound if there is no such user or role.
+func (s *Storage) SetUserRole(ctx context.Context, login, role string) error {
+	res, err := s.db.ExecContext(ctx, "UPDATE users SET role=$1 WHERE login=$2", role, login)
+	if err != nil {
+		log.Println("problem with setting user role", err)
+		return translateError(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// searchQuery builds the tsquery matching the text in both the Russian and the English configurations.
+const searchQuery = "websearch_to_tsquery('russian', $1) || websearch_to_tsquery('english', $1)"
+
+// escapeHTML returns the SQL expression escaping the text of expr the way html.EscapeString does.
+// Snippets are escaped before ts_headline adds the highlight markers, so they are safe to render as HTML.
+func escapeHTML(expr string) string {
+	return "replace(replace(replace(replace(replace(" + expr +
+		", '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '''', '&#39;'), '\"', '&#34;')"
+}
+
+// setFuzzyThreshold makes the pg_trgm <% operator match at repository.FuzzyThreshold until the
+// transaction ends. Unlike a comparison of word_similarity, the operator can use the trigram indexes.
+var setFuzzyThreshold = fmt.Sprintf("SET LOCAL pg_trgm.word_similarity_threshold = %g", repository.FuzzyThreshold)
+
+// searchFilmsQuery selects the films whose own text matches or whose name is similar to the query,
+// along with the films of the cast members matching the same way, so every branch is an index scan.
+// word_similarity is only computed for the rank of the matches.
+var searchFilmsQuery = `WITH q AS (SELECT ` + searchQuery + ` AS ts),
+	cast_match AS (
+		SELECT af.film_id, max(greatest(ts_rank(a.search, q.ts), word_similarity($1, a.full_name))) AS score
+		FROM actors a CROSS JOIN q JOIN actorsfilms af ON af.actor_id = a.id
+		WHERE a.search @@ q.ts OR $1 <% a.full_name
+		GROUP BY af.film_id
+	),
+	matches AS (
+		SELECT f.id FROM films f CROSS JOIN q WHERE f.search @@ q.ts OR $1 <% f.name
+		UNION
+		SELECT film_id FROM cast_match
+	)
+	SELECT f.id, f.name, f.description, f.date, f.rating, f.votes,
+		ts_headline('russian', ` + escapeHTML("f.name || '. ' || f.description") + `, q.ts, $2),
+		greatest(ts_rank(f.search, q.ts), word_similarity($1, f.name), coalesce(cast_match.score, 0)) AS rank
+	FROM matches JOIN films f ON f.id = matches.id CROSS JOIN q
+	LEFT JOIN cast_match ON cast_match.film_id = f.id
+	ORDER BY rank DESC, f.id
+	LIMIT $3 OFFSET $4`
+
+// searchActorsQuery selects the actors whose full name matches or is similar to the query.
+const searchActorsQuery = `WITH q AS (SELECT ` + searchQuery + ` AS ts)
+	SELECT a.id, a.full_name, greatest(ts_rank(a.search, q.ts), word_similarity($1, a.full_name)) AS rank
+	FROM actors a CROSS JOIN q
+	WHERE a.search @@ q.ts OR $1 <% a.full_name
+	ORDER BY rank DESC, a.id
+	LIMIT $2 OFFSET $3`
+
+// SearchFilms runs the full-text search over the film names, descriptions and cast names.
+//
+// A film matches if its own text or the full name of anyone in its cast matches the query,
+// or if the film name or a cast name is similar enough to the query to be a typo.
+// Hits are ordered by the best of the text rank and the trigram similarity.
+func (s *Storage) SearchFilms(ctx context.Context, query string, limit, offset int) ([]structures.FilmHit, error) {
+	if err := repository.NormalizeSearch(&query, &limit, offset); err != nil {
+		return nil, err
+	}
+	headline := fmt.Sprintf("StartSel=%s, StopSel=%s, MaxFragments=2, MaxWords=25, MinWords=8", repository.HighlightStart, repository.HighlightStop)
+	var hits []structures.FilmHit
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, setFuzzyThreshold); err != nil {
+			return err
+		}
+		rows, err := tx.QueryContext(ctx, searchFilmsQuery, query, headline, limit, offset)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var hit structures.FilmHit
+			err := rows.Scan(&hit.Id, &hit.Name, &hit.Description, &hit.Date, &hit.Rating, &hit.Votes, &hit.Snippet, &hit.Rank)
+			if err != nil {
+				return err
+			}
+			hits = append(hits, hit)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		log.Println("problem with searching films", err)
+		return nil, err
+	}
+	films := make([]structures.Film, len(hits))
+	for i := range hits {
+		films[i] = hits[i].Film
+	}
+	if err := s.attachGenres(ctx, films); err != nil {
+		return nil, err
+	}
+	for i := range hits {
+		hits[i].Genres = films[i].Genres
+	}
+	return hits, nil
+}
+
+// SearchActors runs the full-text search over the actor full names, typos are matched by trigram similarity.
+func (s *Storage) SearchActors(ctx context.Context, query string, limit, offset int) ([]structures.ActorHit, error) {
+	if err := repository.NormalizeSearch(&query, &limit, offset); err != nil {
+		return nil, err
+	}
+	var hits []structures.ActorHit
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, setFuzzyThreshold); err != nil {
+			return err
+		}
+		rows, err := tx.QueryContext(ctx, searchActorsQuery, query, limit, offset)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var hit structures.ActorHit
+			if err := rows.Scan(&hit.Id, &hit.FullName, &hit.Rank); err != nil {
+				return err
+			}
+			hits = append(hits, hit)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		log.Println("problem with searching actors", err)
+		return nil, err
+	}
+	return hits, nil
+}
@@ -0,0 +1,69 @@
+package postgresql
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestSearchPlan checks that the typo matching of the search goes through the trigram indexes
+// instead of computing the similarity of every film and actor. The rows are inserted in a transaction
+// rolled back at the end.
+func TestSearchPlan(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	for _, statement := range []string{
+		`INSERT INTO films (name, description, date)
+			SELECT 'Фильм ' || md5(i::text), 'Описание ' || md5(i::text), '20000101' FROM generate_series(1, 50000) i`,
+		`INSERT INTO actors (name, surname, birthdate, sex)
+			SELECT 'Имя' || md5(i::text), 'Фамилия' || md5(i::text), '19800101', 'm' FROM generate_series(1, 50000) i`,
+		"ANALYZE films",
+		"ANALYZE actors",
+		setFuzzyThreshold,
+	} {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		args    []interface{}
+		indexes []string
+	}{
+		{"films", searchFilmsQuery, []interface{}{"Затмене", "", 20, 0}, []string{"films_name_trgm_idx", "actors_full_name_trgm_idx"}},
+		{"actors", searchActorsQuery, []interface{}{"Ривс", 20, 0}, []string{"actors_full_name_trgm_idx"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := tx.QueryContext(ctx, "EXPLAIN "+tt.query, tt.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+			var lines []string
+			for rows.Next() {
+				var line string
+				if err := rows.Scan(&line); err != nil {
+					t.Fatal(err)
+				}
+				lines = append(lines, line)
+			}
+			if err := rows.Err(); err != nil {
+				t.Fatal(err)
+			}
+			plan := strings.Join(lines, "\n")
+			for _, index := range tt.indexes {
+				if !strings.Contains(plan, index) {
+					t.Fatalf("the search does not use %s:\n%s", index, plan)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// CreateSession stores a new session along with its first refresh token.
+func (s *Storage) CreateSession(ctx context.Context, session structures.Session, token structures.RefreshToken) error {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "INSERT INTO sessions (id, login) VALUES ($1, $2)", session.Id, session.Login)
+		if err != nil {
+			return translateError(err)
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO refreshtokens (token_hash, session_id, expires_at) VALUES ($1, $2, $3)",
+			token.Hash, session.Id, token.ExpiresAt)
+		return err
+	})
+	if err != nil {
+		log.Println("problem with creating session", err)
+		return err
+	}
+	return nil
+}
+
+// RotateRefreshToken marks the refresh token as used and stores the next one in the same session.
+//
+// A used token presented again revokes the whole session and repository.ErrTokenReused is returned.
+func (s *Storage) RotateRefreshToken(ctx context.Context, hash string, next structures.RefreshToken) (structures.Session, error) {
+	var (
+		session   structures.Session
+		expiresAt time.Time
+		used      bool
+		revoked   bool
+		reused    bool
+	)
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `SELECT s.id, s.login, s.created_at, rt.expires_at, rt.used_at IS NOT NULL, s.revoked_at IS NOT NULL
+			FROM refreshtokens rt JOIN sessions s ON s.id = rt.session_id
+			WHERE rt.token_hash = $1 FOR UPDATE`, hash).
+			Scan(&session.Id, &session.Login, &session.CreatedAt, &expiresAt, &used, &revoked)
+		if err != nil {
+			return translateError(err)
+		}
+		if revoked || !expiresAt.After(time.Now()) {
+			return repository.ErrNotFound
+		}
+		if used {
+			reused = true
+			_, err = tx.ExecContext(ctx, "UPDATE sessions SET revoked_at = now() WHERE id = $1", session.Id)
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, "UPDATE refreshtokens SET used_at = now() WHERE token_hash = $1", hash); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO refreshtokens (token_hash, session_id, expires_at) VALUES ($1, $2, $3)",
+			next.Hash, session.Id, next.ExpiresAt)
+		return err
+	})
+	if err != nil {
+		log.Println("problem with rotating refresh token", err)
+		return structures.Session{}, err
+	}
+	if reused {
+		log.Printf("refresh token reused, session %s of %s revoked\n", session.Id, session.Login)
+		return structures.Session{}, repository.ErrTokenReused
+	}
+	return session, nil
+}
+
+// RevokeSession ends the session.
+func (s *Storage) RevokeSession(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE sessions SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL", id)
+	if err != nil {
+		log.Println("problem with revoking session", err)
+		return err
+	}
+	return nil
+}
+
+// RevokeUserSessions ends every session of the user except the one given.
+func (s *Storage) RevokeUserSessions(ctx context.Context, login, exceptID string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE sessions SET revoked_at = now() WHERE login = $1 AND id <> $2 AND revoked_at IS NULL", login, exceptID)
+	if err != nil {
+		log.Println("problem with revoking sessions", err)
+		return err
+	}
+	return nil
+}
+
+// IsSessionRevoked reports whether the session was revoked, unknown sessions count as revoked.
+func (s *Storage) IsSessionRevoked(ctx context.Context, id string) (bool, error) {
+	var revoked bool
+	err := s.db.QueryRowContext(ctx, "SELECT revoked_at IS NOT NULL FROM sessions WHERE id = $1", id).Scan(&revoked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return true, nil
+	}
+	if err != nil {
+		log.Println("problem with checking session", err)
+		return false, err
+	}
+	return revoked, nil
+}
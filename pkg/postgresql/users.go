@@ -0,0 +1,165 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+
+	"github.com/lib/pq"
+)
+
+// profileFields are the users columns in the order scanProfile reads them.
+const profileFields = "login, coalesce(display_name, ''), coalesce(email, ''), language, role, disabled, created_at"
+
+// scanProfile reads a row selecting profileFields.
+func scanProfile(row interface{ Scan(...interface{}) error }) (structures.Profile, error) {
+	var p structures.Profile
+	err := row.Scan(&p.Login, &p.DisplayName, &p.Email, &p.Language, &p.Role, &p.Disabled, &p.CreatedAt)
+	return p, err
+}
+
+// GetProfile returns the profile of the user.
+//
+// It returns repository.ErrNotFound if there is no such user.
+func (s *Storage) GetProfile(ctx context.Context, login string) (structures.Profile, error) {
+	p, err := scanProfile(s.db.QueryRowContext(ctx, "SELECT "+profileFields+" FROM users WHERE login = $1", login))
+	if err != nil {
+		return p, translateError(err)
+	}
+	return p, nil
+}
+
+// UpdateProfile changes the non-nil fields of the profile, empty display names and emails are stored as NULL.
+//
+// It returns repository.ErrInvalidProfile for malformed values, repository.ErrNotFound if there is no such user
+// and repository.ErrAlreadyExists if the email is taken.
+func (s *Storage) UpdateProfile(ctx context.Context, login string, update structures.ProfileUpdate) error {
+	if err := repository.NormalizeProfileUpdate(&update); err != nil {
+		return err
+	}
+	var b queryBuilder
+	var sets []string
+	if update.DisplayName != nil {
+		sets = append(sets, "display_name="+b.arg(nullString(*update.DisplayName)))
+	}
+	if update.Email != nil {
+		sets = append(sets, "email="+b.arg(nullString(*update.Email)))
+	}
+	if update.Language != nil {
+		sets = append(sets, "language="+b.arg(*update.Language))
+	}
+	if len(sets) == 0 {
+		log.Println("no fields to update")
+		_, err := s.GetProfile(ctx, login)
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, "UPDATE users SET "+strings.Join(sets, ", ")+" WHERE login="+b.arg(login), b.args...)
+	if err != nil {
+		log.Println("problem with updating profile", err)
+		return translateError(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// DelUser deletes the user, the reviews, sessions and reset tokens go by cascade,
+// then the ratings of the films the user reviewed are recomputed.
+//
+// It returns repository.ErrNotFound if there is no such user.
+func (s *Storage) DelUser(ctx context.Context, login string) error {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		// lock the reviewed films in id order like lockFilm does for a single review
+		var films []int64
+		err := tx.QueryRowContext(ctx, `SELECT coalesce(array_agg(id), '{}') FROM (
+			SELECT id FROM films WHERE id IN (SELECT film_id FROM reviews WHERE login = $1) ORDER BY id FOR UPDATE) locked`,
+			login).Scan(pq.Array(&films))
+		if err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, "DELETE FROM users WHERE login = $1", login)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return repository.ErrNotFound
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE films f SET
+			rating = coalesce((SELECT avg(r.score) FROM reviews r WHERE r.film_id = f.id), 0),
+			votes = (SELECT count(*) FROM reviews r WHERE r.film_id = f.id)
+			WHERE f.id = ANY($1)`, pq.Array(films))
+		return err
+	})
+	if err != nil {
+		log.Println("problem with deleting user", err)
+		return err
+	}
+	return nil
+}
+
+// ListUsers returns a page of users ordered by login whose login, display name or email contain the query, ignoring case.
+func (s *Storage) ListUsers(ctx context.Context, q structures.UserListQuery) (structures.UserList, error) {
+	var list structures.UserList
+	if err := repository.NormalizePage(&q.Limit, q.Offset); err != nil {
+		return list, err
+	}
+	var b queryBuilder
+	if q.Query != "" {
+		pattern := b.arg(containing(q.Query)) + ` ESCAPE '\'`
+		b.conditions = append(b.conditions, "(login ILIKE "+pattern+" OR display_name ILIKE "+pattern+" OR email ILIKE "+pattern+")")
+	}
+	if q.Disabled != nil {
+		b.conditions = append(b.conditions, "disabled = "+b.arg(*q.Disabled))
+	}
+	err := s.db.QueryRowContext(ctx, "SELECT count(*) FROM users"+b.where(), b.args...).Scan(&list.Total)
+	if err != nil {
+		log.Println("problem with counting users", err)
+		return list, err
+	}
+	rows, err := s.db.QueryContext(ctx, "SELECT "+profileFields+" FROM users"+b.where()+
+		" ORDER BY login LIMIT "+b.arg(q.Limit)+" OFFSET "+b.arg(q.Offset), b.args...)
+	if err != nil {
+		log.Println("problem with listing users", err)
+		return list, err
+	}
+	defer rows.Close()
+	list.Users = []structures.Profile{}
+	for rows.Next() {
+		p, err := scanProfile(rows)
+		if err != nil {
+			return list, err
+		}
+		list.Users = append(list.Users, p)
+	}
+	return list, rows.Err()
+}
+
+// SetUserDisabled disables or re-enables the user. Disabling also ends every session of the user.
+//
+// It returns repository.ErrNotFound if there is no such user.
+func (s *Storage) SetUserDisabled(ctx context.Context, login string, disabled bool) error {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "UPDATE users SET disabled = $1 WHERE login = $2", disabled, login)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return repository.ErrNotFound
+		}
+		if !disabled {
+			return nil
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE sessions SET revoked_at = now() WHERE login = $1 AND revoked_at IS NULL", login)
+		return err
+	})
+	if err != nil {
+		log.Println("problem with disabling user", err)
+		return err
+	}
+	return nil
+}
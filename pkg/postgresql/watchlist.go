@@ -0,0 +1,162 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// joinedFilmFields are filmFields of the films table joined as f.
+const joinedFilmFields = "f.id, f.name, f.description, f.date, f.rating, f.votes"
+
+// AddToWatchlist puts the film on the watchlist of the user.
+//
+// It returns repository.ErrNotFound if there is no such film and repository.ErrAlreadyExists if it is already listed.
+func (s *Storage) AddToWatchlist(ctx context.Context, login string, filmID int) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO watchlist (login, film_id) VALUES ($1, $2)", login, filmID)
+	if err != nil {
+		log.Println("problem with adding film to watchlist", err)
+		return translateError(err)
+	}
+	return nil
+}
+
+// RemoveFromWatchlist takes the film off the watchlist of the user.
+func (s *Storage) RemoveFromWatchlist(ctx context.Context, login string, filmID int) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM watchlist WHERE login = $1 AND film_id = $2", login, filmID)
+	if err != nil {
+		log.Println("problem with removing film from watchlist", err)
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// GetWatchlist returns a page of the watchlist of the user, the latest added first.
+func (s *Storage) GetWatchlist(ctx context.Context, login string, limit, offset int) (structures.Watchlist, error) {
+	var list structures.Watchlist
+	if err := repository.NormalizePage(&limit, offset); err != nil {
+		return list, err
+	}
+	err := s.db.QueryRowContext(ctx, "SELECT count(*) FROM watchlist WHERE login = $1", login).Scan(&list.Total)
+	if err != nil {
+		log.Println("problem with counting watchlist", err)
+		return list, err
+	}
+	rows, err := s.db.QueryContext(ctx, "SELECT "+joinedFilmFields+`, w.added_at FROM watchlist w JOIN films f ON f.id = w.film_id
+		WHERE w.login = $1 ORDER BY w.added_at DESC, f.id DESC LIMIT $2 OFFSET $3`, login, limit, offset)
+	if err != nil {
+		log.Println("problem with getting watchlist", err)
+		return list, err
+	}
+	var addedAt []time.Time
+	films, err := scanJoinedFilms(rows, func() interface{} {
+		addedAt = append(addedAt, time.Time{})
+		return &addedAt[len(addedAt)-1]
+	})
+	if err != nil {
+		return list, err
+	}
+	if err := s.attachGenres(ctx, films); err != nil {
+		return list, err
+	}
+	list.Films = make([]structures.WatchlistEntry, len(films))
+	for i, film := range films {
+		list.Films[i] = structures.WatchlistEntry{Film: film, AddedAt: addedAt[i]}
+	}
+	return list, nil
+}
+
+// MarkWatched records that the user watched the film on the date and takes it off the watchlist.
+//
+// It returns repository.ErrInvalidWatch for malformed or future dates and repository.ErrNotFound if there is no such film.
+func (s *Storage) MarkWatched(ctx context.Context, login string, watched structures.WatchedFilm) error {
+	if err := repository.NormalizeWatched(&watched, time.Now()); err != nil {
+		return err
+	}
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "INSERT INTO watched (login, film_id, watched_on) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+			login, watched.FilmID, watched.Date)
+		if err != nil {
+			return translateError(err)
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM watchlist WHERE login = $1 AND film_id = $2", login, watched.FilmID)
+		return err
+	})
+	if err != nil {
+		log.Println("problem with marking film as watched", err)
+		return err
+	}
+	return nil
+}
+
+// UnmarkWatched deletes the viewing of the film on the date or, if the date is empty, every viewing of it.
+func (s *Storage) UnmarkWatched(ctx context.Context, login string, watched structures.WatchedFilm) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM watched WHERE login = $1 AND film_id = $2 AND ($3 = '' OR watched_on = $3)",
+		login, watched.FilmID, watched.Date)
+	if err != nil {
+		log.Println("problem with deleting viewing", err)
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// GetWatchHistory returns a page of the viewings of the user, the latest first.
+func (s *Storage) GetWatchHistory(ctx context.Context, login string, limit, offset int) (structures.WatchHistory, error) {
+	var history structures.WatchHistory
+	if err := repository.NormalizePage(&limit, offset); err != nil {
+		return history, err
+	}
+	err := s.db.QueryRowContext(ctx, "SELECT count(*) FROM watched WHERE login = $1", login).Scan(&history.Total)
+	if err != nil {
+		log.Println("problem with counting watch history", err)
+		return history, err
+	}
+	rows, err := s.db.QueryContext(ctx, "SELECT "+joinedFilmFields+`, w.watched_on FROM watched w JOIN films f ON f.id = w.film_id
+		WHERE w.login = $1 ORDER BY w.watched_on DESC, f.id DESC LIMIT $2 OFFSET $3`, login, limit, offset)
+	if err != nil {
+		log.Println("problem with getting watch history", err)
+		return history, err
+	}
+	var dates []string
+	films, err := scanJoinedFilms(rows, func() interface{} {
+		dates = append(dates, "")
+		return &dates[len(dates)-1]
+	})
+	if err != nil {
+		return history, err
+	}
+	if err := s.attachGenres(ctx, films); err != nil {
+		return history, err
+	}
+	history.Entries = make([]structures.WatchedEntry, len(films))
+	for i, film := range films {
+		history.Entries[i] = structures.WatchedEntry{Film: film, Date: dates[i]}
+	}
+	return history, nil
+}
+
+// scanJoinedFilms reads the rows of a query selecting joinedFilmFields followed by one more column,
+// scanned into the destination extra returns for every row.
+func scanJoinedFilms(rows *sql.Rows, extra func() interface{}) ([]structures.Film, error) {
+	defer rows.Close()
+	var films []structures.Film
+	for rows.Next() {
+		film := structures.Film{}
+		err := rows.Scan(&film.Id, &film.Name, &film.Description, &film.Date, &film.Rating, &film.Votes, extra())
+		if err != nil {
+			return nil, err
+		}
+		films = append(films, film)
+	}
+	return films, rows.Err()
+}
@@ -0,0 +1,242 @@
+// Package recommend scores films against each other and against the history of a user.
+//
+// Scoring is deterministic: it only depends on the features of the films and the signals
+// of the user, and ties are broken by rating and then by film id.
+package recommend
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	st "VK_app/internal/structures"
+	"VK_app/pkg/repository"
+)
+
+// Weights of one shared feature of two films.
+const (
+	ActorWeight    = 3.0
+	DirectorWeight = 4.0
+	CrewWeight     = 1.0
+	GenreWeight    = 2.0
+)
+
+// WatchedWeight is the weight of a film the user watched without rating it.
+// Rated films weigh from -1 for a score of 1 to 1 for a score of 10.
+const WatchedWeight = 0.5
+
+// CandidateFactor is how many related films are fetched for every film recommended. The storage ranks
+// them by the same weights, the factor leaves room for the ties it breaks differently.
+const CandidateFactor = 3
+
+// maxReasons is how many films at most explain a personal recommendation.
+const maxReasons = 3
+
+// Match is what two films share and how similar it makes them.
+type Match struct {
+	People []st.CreditedPerson
+	Genres []string
+	Score  float64
+}
+
+// Compare returns what the two films share. People count when they are credited in both films
+// with the same credit type, an actor in one film directing the other is no match.
+func Compare(a, b st.FilmFeatures) Match {
+	var m Match
+	credited := make(map[st.CreditedPerson]bool, len(a.People))
+	for _, p := range a.People {
+		credited[p] = true
+	}
+	for _, p := range b.People {
+		if credited[p] {
+			m.People = append(m.People, p)
+			m.Score += personWeight(p.CreditType)
+		}
+	}
+	genres := make(map[string]bool, len(a.Film.Genres))
+	for _, g := range a.Film.Genres {
+		genres[g] = true
+	}
+	for _, g := range b.Film.Genres {
+		if genres[g] {
+			m.Genres = append(m.Genres, g)
+			m.Score += GenreWeight
+		}
+	}
+	return m
+}
+
+func personWeight(creditType string) float64 {
+	switch creditType {
+	case repository.CreditActor:
+		return ActorWeight
+	case "director":
+		return DirectorWeight
+	default:
+		return CrewWeight
+	}
+}
+
+// Explain describes the match as "same cast: ...", "same director: ..." and "same genres: ..." parts.
+func (m Match) Explain() []string {
+	var parts []string
+	var types []string
+	names := map[string][]string{}
+	for _, p := range m.People {
+		if names[p.CreditType] == nil {
+			types = append(types, p.CreditType)
+		}
+		names[p.CreditType] = append(names[p.CreditType], p.FullName)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		label := t
+		if t == repository.CreditActor {
+			label = "cast"
+		}
+		parts = append(parts, "same "+label+": "+strings.Join(names[t], ", "))
+	}
+	if len(m.Genres) > 0 {
+		parts = append(parts, "same genres: "+strings.Join(m.Genres, ", "))
+	}
+	return parts
+}
+
+// Similar returns at most limit films of the candidates most similar to the target,
+// leaving out the target itself and the films sharing nothing with it.
+func Similar(target st.FilmFeatures, candidates []st.FilmFeatures, limit int) []st.Recommendation {
+	var recs []st.Recommendation
+	for _, c := range candidates {
+		if c.Film.Id == target.Film.Id {
+			continue
+		}
+		m := Compare(target, c)
+		if m.Score > 0 {
+			recs = append(recs, st.Recommendation{Film: c.Film, Score: round(m.Score), Reasons: m.Explain()})
+		}
+	}
+	return best(recs, limit)
+}
+
+// SignalWeight returns how much the user liked the film: the rating mapped to [-1, 1]
+// or WatchedWeight for a film watched without rating it.
+func SignalWeight(s st.UserSignal) float64 {
+	if s.Score > 0 {
+		return (float64(s.Score) - 5.5) / 4.5
+	}
+	if s.Watched {
+		return WatchedWeight
+	}
+	return 0
+}
+
+// contribution is the part of the score of a candidate coming from one film of the user.
+type contribution struct {
+	seed   st.FilmFeatures
+	signal st.UserSignal
+	match  Match
+	score  float64
+}
+
+// ForUser returns at most limit films the user neither rated nor watched, scored by their similarity
+// to the films of the user weighted by SignalWeight: films like the disliked ones lose score.
+// Films scoring zero or less are left out. films must hold the features of the films of the signals.
+func ForUser(signals []st.UserSignal, films []st.FilmFeatures, limit int) []st.Recommendation {
+	signals = append([]st.UserSignal{}, signals...)
+	sort.Slice(signals, func(i, j int) bool { return signals[i].FilmID < signals[j].FilmID })
+	byID := make(map[int]st.FilmFeatures, len(films))
+	for _, f := range films {
+		byID[f.Film.Id] = f
+	}
+	seen := make(map[int]bool, len(signals))
+	for _, s := range signals {
+		seen[s.FilmID] = true
+	}
+	var recs []st.Recommendation
+	for _, candidate := range films {
+		if seen[candidate.Film.Id] {
+			continue
+		}
+		total := 0.0
+		var liked []contribution
+		for _, s := range signals {
+			seed, ok := byID[s.FilmID]
+			weight := SignalWeight(s)
+			if !ok || weight == 0 {
+				continue
+			}
+			m := Compare(seed, candidate)
+			score := weight * m.Score
+			total += score
+			if score > 0 {
+				liked = append(liked, contribution{seed: seed, signal: s, match: m, score: score})
+			}
+		}
+		if total <= 0 {
+			continue
+		}
+		recs = append(recs, st.Recommendation{Film: candidate.Film, Score: round(total), Reasons: explainUser(liked)})
+	}
+	return best(recs, limit)
+}
+
+// explainUser describes the films of the user contributing the most to a recommendation.
+func explainUser(liked []contribution) []string {
+	sort.SliceStable(liked, func(i, j int) bool { return liked[i].score > liked[j].score })
+	if len(liked) > maxReasons {
+		liked = liked[:maxReasons]
+	}
+	reasons := make([]string, 0, len(liked))
+	for _, c := range liked {
+		because := fmt.Sprintf("you watched «%s»", c.seed.Film.Name)
+		if c.signal.Score > 0 {
+			because = fmt.Sprintf("you rated «%s» %d/10", c.seed.Film.Name, c.signal.Score)
+		}
+		reasons = append(reasons, because+"; "+strings.Join(c.match.Explain(), "; "))
+	}
+	return reasons
+}
+
+// Popular turns the best rated films into recommendations for users with no history to go by,
+// leaving out the films of the signals.
+func Popular(films []st.Film, signals []st.UserSignal, limit int) []st.Recommendation {
+	seen := make(map[int]bool, len(signals))
+	for _, s := range signals {
+		seen[s.FilmID] = true
+	}
+	var recs []st.Recommendation
+	for _, f := range films {
+		if seen[f.Id] || f.Votes == 0 {
+			continue
+		}
+		recs = append(recs, st.Recommendation{Film: f, Score: round(float64(f.Rating)),
+			Reasons: []string{fmt.Sprintf("rated %.1f by %d users", f.Rating, f.Votes)}})
+	}
+	return best(recs, limit)
+}
+
+// best sorts the recommendations by score, rating and id and keeps at most limit of them.
+func best(recs []st.Recommendation, limit int) []st.Recommendation {
+	sort.Slice(recs, func(i, j int) bool {
+		if recs[i].Score != recs[j].Score {
+			return recs[i].Score > recs[j].Score
+		}
+		if recs[i].Film.Rating != recs[j].Film.Rating {
+			return recs[i].Film.Rating > recs[j].Film.Rating
+		}
+		return recs[i].Film.Id < recs[j].Film.Id
+	})
+	if len(recs) > limit {
+		recs = recs[:limit]
+	}
+	if recs == nil {
+		recs = []st.Recommendation{}
+	}
+	return recs
+}
+
+// round keeps two decimals of the score, so that equal scores compare equal.
+func round(score float64) float64 {
+	return math.Round(score*100) / 100
+}
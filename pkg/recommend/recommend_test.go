@@ -0,0 +1,175 @@
+package recommend
+
+import (
+	"reflect"
+	"testing"
+
+	st "VK_app/internal/structures"
+)
+
+var (
+	keanuActor    = st.CreditedPerson{ActorID: 1, FullName: "Киану Ривз", CreditType: "actor"}
+	keanuDirector = st.CreditedPerson{ActorID: 1, FullName: "Киану Ривз", CreditType: "director"}
+	lanaDirector  = st.CreditedPerson{ActorID: 2, FullName: "Лана Вачовски", CreditType: "director"}
+	lanaWriter    = st.CreditedPerson{ActorID: 2, FullName: "Лана Вачовски", CreditType: "writer"}
+	carrieActor   = st.CreditedPerson{ActorID: 3, FullName: "Кэрри-Энн Мосс", CreditType: "actor"}
+)
+
+func features(id int, rating float32, genres []string, people ...st.CreditedPerson) st.FilmFeatures {
+	return st.FilmFeatures{Film: st.Film{Id: id, Name: "Фильм", Rating: rating, Genres: genres}, People: people}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  st.FilmFeatures
+		score float64
+	}{
+		{"nothing shared", features(1, 0, []string{"драма"}, keanuActor), features(2, 0, []string{"комедия"}, carrieActor), 0},
+		{"actor", features(1, 0, nil, keanuActor), features(2, 0, nil, keanuActor), ActorWeight},
+		{"director", features(1, 0, nil, lanaDirector), features(2, 0, nil, lanaDirector), DirectorWeight},
+		{"other crew", features(1, 0, nil, lanaWriter), features(2, 0, nil, lanaWriter), CrewWeight},
+		{"genre", features(1, 0, []string{"боевик", "драма"}, keanuActor), features(2, 0, []string{"боевик"}), GenreWeight},
+		{"actor in one film directs the other", features(1, 0, nil, keanuActor), features(2, 0, nil, keanuDirector), 0},
+		{"everything adds up", features(1, 0, []string{"боевик", "фантастика"}, keanuActor, carrieActor, lanaDirector),
+			features(2, 0, []string{"фантастика", "боевик"}, keanuActor, carrieActor, lanaDirector, lanaWriter),
+			2*ActorWeight + DirectorWeight + 2*GenreWeight},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if m := Compare(tt.a, tt.b); m.Score != tt.score {
+				t.Fatalf("score is %v, want %v (%+v)", m.Score, tt.score, m)
+			}
+			if m := Compare(tt.b, tt.a); m.Score != tt.score {
+				t.Fatalf("score the other way round is %v, want %v", m.Score, tt.score)
+			}
+		})
+	}
+}
+
+func TestExplain(t *testing.T) {
+	m := Compare(
+		features(1, 0, []string{"боевик", "фантастика"}, keanuActor, carrieActor, lanaDirector),
+		features(2, 0, []string{"боевик", "фантастика"}, keanuActor, carrieActor, lanaDirector),
+	)
+	want := []string{
+		"same cast: Киану Ривз, Кэрри-Энн Мосс",
+		"same director: Лана Вачовски",
+		"same genres: боевик, фантастика",
+	}
+	if got := m.Explain(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Explain() = %q, want %q", got, want)
+	}
+	if got := (Match{}).Explain(); len(got) != 0 {
+		t.Fatalf("Explain() of no match = %q", got)
+	}
+}
+
+func TestSignalWeight(t *testing.T) {
+	tests := []struct {
+		signal st.UserSignal
+		want   float64
+	}{
+		{st.UserSignal{Score: 10}, 1},
+		{st.UserSignal{Score: 1}, -1},
+		{st.UserSignal{Score: 1, Watched: true}, -1},
+		{st.UserSignal{Watched: true}, WatchedWeight},
+		{st.UserSignal{}, 0},
+	}
+	for _, tt := range tests {
+		if got := SignalWeight(tt.signal); got != tt.want {
+			t.Errorf("SignalWeight(%+v) = %v, want %v", tt.signal, got, tt.want)
+		}
+	}
+}
+
+func TestForUser(t *testing.T) {
+	liked := features(1, 0, []string{"боевик"}, keanuActor)
+	disliked := features(2, 0, []string{"драма"}, carrieActor)
+	candidate := features(3, 0, []string{"драма"}, keanuActor)
+	films := []st.FilmFeatures{liked, disliked, candidate}
+
+	tests := []struct {
+		name    string
+		signals []st.UserSignal
+		want    []float64
+	}{
+		{"liked film", []st.UserSignal{{FilmID: 1, Score: 10}}, []float64{ActorWeight}},
+		{"watched film", []st.UserSignal{{FilmID: 1, Watched: true}}, []float64{WatchedWeight * ActorWeight}},
+		{"negative signal lowers the score", []st.UserSignal{{FilmID: 1, Score: 10}, {FilmID: 2, Score: 1}}, []float64{ActorWeight - GenreWeight}},
+		{"negative signal outweighs", []st.UserSignal{{FilmID: 1, Watched: true}, {FilmID: 2, Score: 1}}, nil},
+		{"only disliked films", []st.UserSignal{{FilmID: 2, Score: 1}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recs := ForUser(tt.signals, films, 10)
+			var scores []float64
+			for _, r := range recs {
+				if r.Film.Id != candidate.Film.Id {
+					t.Fatalf("film %d of the user was recommended", r.Film.Id)
+				}
+				scores = append(scores, r.Score)
+			}
+			if !reflect.DeepEqual(scores, tt.want) {
+				t.Fatalf("scores are %v, want %v", scores, tt.want)
+			}
+		})
+	}
+
+	recs := ForUser([]st.UserSignal{{FilmID: 1, Score: 9}}, films, 10)
+	want := []string{"you rated «Фильм» 9/10; same cast: Киану Ривз"}
+	if len(recs) != 1 || !reflect.DeepEqual(recs[0].Reasons, want) {
+		t.Fatalf("recommendations are %+v, want one with reasons %q", recs, want)
+	}
+}
+
+func TestSimilarOrderAndLimit(t *testing.T) {
+	target := features(1, 0, []string{"боевик"}, keanuActor)
+	candidates := []st.FilmFeatures{
+		target,
+		features(5, 7.0, []string{"боевик"}),
+		features(4, 8.0, []string{"боевик"}),
+		features(3, 7.0, []string{"боевик"}),
+		features(2, 6.0, nil, keanuActor),
+		features(6, 9.0, []string{"драма"}),
+	}
+	tests := []struct {
+		limit int
+		want  []int
+	}{
+		// the actor outweighs the genre, equal scores go by rating and then by id
+		{10, []int{2, 4, 3, 5}},
+		{2, []int{2, 4}},
+		{0, []int{}},
+	}
+	for _, tt := range tests {
+		recs := Similar(target, candidates, tt.limit)
+		ids := []int{}
+		for _, r := range recs {
+			ids = append(ids, r.Film.Id)
+		}
+		if !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("Similar with limit %d = %v, want %v", tt.limit, ids, tt.want)
+		}
+	}
+}
+
+func TestPopular(t *testing.T) {
+	films := []st.Film{
+		{Id: 1, Rating: 8, Votes: 10},
+		{Id: 2, Rating: 9, Votes: 3},
+		{Id: 3, Rating: 0, Votes: 0},
+		{Id: 4, Rating: 7, Votes: 1},
+	}
+	recs := Popular(films, []st.UserSignal{{FilmID: 2, Watched: true}}, 10)
+	ids := []int{}
+	for _, r := range recs {
+		ids = append(ids, r.Film.Id)
+	}
+	if want := []int{1, 4}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("Popular = %v, want %v", ids, want)
+	}
+	if want := []string{"rated 8.0 by 10 users"}; !reflect.DeepEqual(recs[0].Reasons, want) {
+		t.Fatalf("reasons are %q, want %q", recs[0].Reasons, want)
+	}
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	st "VK_app/internal/structures"
+)
+
+const (
+	// MaxCollectionName is the longest collection name that can be stored.
+	MaxCollectionName = 100
+	// MaxCollectionDescription is the longest collection description that can be stored.
+	MaxCollectionDescription = 1000
+	// maxSlugBase is the longest part of a slug made of the collection name.
+	maxSlugBase = 60
+)
+
+// ErrInvalidCollection is returned when a collection has an empty or too long name or description
+// or when a new order does not list every film of the collection once.
+var ErrInvalidCollection = errors.New("invalid collection")
+
+// ValidateCollection checks the name and the description of the collection.
+func ValidateCollection(collection st.Collection) error {
+	if strings.TrimSpace(collection.Name) == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidCollection)
+	}
+	if len([]rune(collection.Name)) > MaxCollectionName {
+		return fmt.Errorf("%w: name is longer than %d characters", ErrInvalidCollection, MaxCollectionName)
+	}
+	if len([]rune(collection.Description)) > MaxCollectionDescription {
+		return fmt.Errorf("%w: description is longer than %d characters", ErrInvalidCollection, MaxCollectionDescription)
+	}
+	return nil
+}
+
+// ValidateCollectionUpdate checks the changed fields of a collection.
+func ValidateCollectionUpdate(update st.CollectionUpdate) error {
+	collection := st.Collection{Name: "-"}
+	if update.Name != nil {
+		collection.Name = *update.Name
+	}
+	if update.Description != nil {
+		collection.Description = *update.Description
+	}
+	return ValidateCollection(collection)
+}
+
+// ValidateOrder checks that order lists every one of the films once.
+func ValidateOrder(films, order []int) error {
+	if len(films) != len(order) {
+		return fmt.Errorf("%w: the order must list all %d films of the collection", ErrInvalidCollection, len(films))
+	}
+	listed := make(map[int]bool, len(films))
+	for _, id := range films {
+		listed[id] = true
+	}
+	for _, id := range order {
+		if !listed[id] {
+			return fmt.Errorf("%w: film %d is not in the collection or listed twice", ErrInvalidCollection, id)
+		}
+		delete(listed, id)
+	}
+	return nil
+}
+
+// NewSlug returns a slug for the collection name: the name transliterated to lowercase
+// latin letters and digits joined by dashes, followed by a random suffix keeping slugs unique.
+func NewSlug(name string) (string, error) {
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	base := slugBase(name)
+	if base == "" {
+		return hex.EncodeToString(b), nil
+	}
+	return base + "-" + hex.EncodeToString(b), nil
+}
+
+// cyrillic maps the lowercase russian letters to their latin transliteration.
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "h", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+}
+
+func slugBase(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		var part string
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			part = string(r)
+		case cyrillic[r] != "":
+			part = cyrillic[r]
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			continue
+		default:
+			dash = b.Len() > 0
+			continue
+		}
+		if b.Len()+len(part) > maxSlugBase {
+			break
+		}
+		if dash {
+			b.WriteByte('-')
+			dash = false
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
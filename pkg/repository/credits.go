@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	st "VK_app/internal/structures"
+)
+
+// CreditActor is the credit type of the people playing in a film.
+const CreditActor = "actor"
+
+// CreditTypes lists the roles a person can be credited with in a film.
+var CreditTypes = []string{CreditActor, "director", "writer", "producer", "composer", "cinematographer"}
+
+// ErrInvalidCredit is returned when a credit has an unknown type or a wrong billing position.
+var ErrInvalidCredit = errors.New("invalid credit")
+
+// NormalizeCredit validates the credit and defaults its type to CreditActor.
+func NormalizeCredit(credit *st.ActorFilm) error {
+	if credit.CreditType == "" {
+		credit.CreditType = CreditActor
+	}
+	known := false
+	for _, t := range CreditTypes {
+		if t == credit.CreditType {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("%w: unknown credit type %q", ErrInvalidCredit, credit.CreditType)
+	}
+	if credit.Billing < 0 {
+		return fmt.Errorf("%w: billing position must be positive", ErrInvalidCredit)
+	}
+	return nil
+}
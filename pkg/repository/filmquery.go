@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	st "VK_app/internal/structures"
+)
+
+// FilmSortFields lists the film fields the listing can be sorted by.
+var FilmSortFields = []string{"rating", "name", "date"}
+
+// NormalizeFilmListQuery validates the query and fills in the defaults:
+// rating in descending order and a page of DefaultLimit films.
+func NormalizeFilmListQuery(q *st.FilmListQuery) error {
+	if len(q.Sort) == 0 {
+		q.Sort = []st.SortKey{{Field: "rating", Order: "desc"}}
+	}
+	seen := map[string]bool{}
+	for i, key := range q.Sort {
+		if !isSortField(key.Field) {
+			return fmt.Errorf("%w: unknown sort field %q", ErrInvalidQuery, key.Field)
+		}
+		if seen[key.Field] {
+			return fmt.Errorf("%w: sort field %q is repeated", ErrInvalidQuery, key.Field)
+		}
+		seen[key.Field] = true
+		switch strings.ToLower(key.Order) {
+		case "", "asc":
+			q.Sort[i].Order = "asc"
+		case "desc":
+			q.Sort[i].Order = "desc"
+		default:
+			return fmt.Errorf("%w: unknown sort order %q", ErrInvalidQuery, key.Order)
+		}
+	}
+	if err := NormalizePage(&q.Limit, q.Offset); err != nil {
+		return err
+	}
+	if q.Cursor != "" && q.Offset != 0 {
+		return fmt.Errorf("%w: cursor and offset can not be used together", ErrInvalidQuery)
+	}
+	if q.RatingFrom != nil && q.RatingTo != nil && *q.RatingFrom > *q.RatingTo {
+		return fmt.Errorf("%w: rating_from is greater than rating_to", ErrInvalidQuery)
+	}
+	for _, date := range []string{q.DateFrom, q.DateTo} {
+		if date != "" && !isDate(date) {
+			return fmt.Errorf("%w: date %q is not in YYYYMMDD format", ErrInvalidQuery, date)
+		}
+	}
+	if q.DateFrom != "" && q.DateTo != "" && q.DateFrom > q.DateTo {
+		return fmt.Errorf("%w: date_from is later than date_to", ErrInvalidQuery)
+	}
+	return nil
+}
+
+// filmCursor is the position of the last film of a page.
+type filmCursor struct {
+	Sort   string  `json:"s"`
+	Id     int     `json:"id"`
+	Name   string  `json:"n,omitempty"`
+	Date   string  `json:"d,omitempty"`
+	Rating float32 `json:"r,omitempty"`
+}
+
+// EncodeFilmCursor returns the opaque cursor pointing right after the given film.
+func EncodeFilmCursor(film st.Film, sort []st.SortKey) string {
+	data, _ := json.Marshal(filmCursor{
+		Sort:   sortSignature(sort),
+		Id:     film.Id,
+		Name:   film.Name,
+		Date:   film.Date,
+		Rating: film.Rating,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeFilmCursor returns the film the cursor points after.
+// The cursor must have been produced for the same sort keys.
+func DecodeFilmCursor(cursor string, sort []st.SortKey) (st.Film, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return st.Film{}, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	var c filmCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return st.Film{}, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	if c.Sort != sortSignature(sort) {
+		return st.Film{}, fmt.Errorf("%w: cursor was issued for another sort order", ErrInvalidQuery)
+	}
+	return st.Film{Id: c.Id, Name: c.Name, Date: c.Date, Rating: c.Rating}, nil
+}
+
+func sortSignature(sort []st.SortKey) string {
+	parts := make([]string, 0, len(sort))
+	for _, key := range sort {
+		parts = append(parts, key.Field+":"+key.Order)
+	}
+	return strings.Join(parts, ",")
+}
+
+func isSortField(field string) bool {
+	for _, f := range FilmSortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func isDate(s string) bool {
+	if len(s) != 8 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
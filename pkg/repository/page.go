@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// DefaultLimit is the page size used when the query does not set one.
+	DefaultLimit = 20
+	// MaxLimit is the largest page size a client may request.
+	MaxLimit = 100
+)
+
+// ErrInvalidQuery is returned when a listing query can not be executed as requested.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// NormalizePage validates the limit and the offset of a page and defaults the limit to DefaultLimit.
+func NormalizePage(limit *int, offset int) error {
+	if *limit == 0 {
+		*limit = DefaultLimit
+	}
+	if *limit < 0 || *limit > MaxLimit {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxLimit)
+	}
+	if offset < 0 {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidQuery)
+	}
+	return nil
+}
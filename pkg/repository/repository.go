@@ -0,0 +1,200 @@
+// Package repository declares the storage interfaces of the film library.
+// Every operation takes the context of the request it serves and gives up once the context is done.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	st "VK_app/internal/structures"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist.
+	ErrNotFound = errors.New("record not found")
+	// ErrAlreadyExists is returned when a record with the same key is already stored.
+	ErrAlreadyExists = errors.New("record already exists")
+)
+
+// FilmRepository describes the storage operations over films.
+// The rating and the votes of a film are computed from its reviews, AddFilm and UpdateFilm ignore them.
+type FilmRepository interface {
+	AddFilm(ctx context.Context, film st.Film) error
+	UpdateFilm(ctx context.Context, film st.Film) error
+	DelFilm(ctx context.Context, id int) error
+	CheckFilm(ctx context.Context, id int) error
+	// GetFilm returns the film along with its genres or ErrNotFound.
+	GetFilm(ctx context.Context, id int) (st.Film, error)
+	// ListFilms returns one page of films matching the query along with the total count.
+	// Invalid queries are reported with ErrInvalidQuery.
+	ListFilms(ctx context.Context, q st.FilmListQuery) (st.FilmList, error)
+	// GetFilmsPieceFilm and GetFilmsPieceActor restrict the result to the genre unless it is empty.
+	GetFilmsPieceFilm(ctx context.Context, piece, genre string) ([]st.Film, error)
+	GetFilmsPieceActor(ctx context.Context, piece, genre string) ([]st.Film, error)
+	// SearchFilms runs the full-text search over the film names, descriptions and cast names
+	// tolerating typos, the best matches first.
+	SearchFilms(ctx context.Context, query string, limit, offset int) ([]st.FilmHit, error)
+}
+
+// ActorRepository describes the storage operations over actors and their credits in films.
+// Actors are not limited to acting: the same person may be credited as a director or a writer.
+type ActorRepository interface {
+	AddActor(ctx context.Context, actor st.Actor) error
+	UpdateActor(ctx context.Context, actor st.Actor) error
+	DelActor(ctx context.Context, id int) error
+	CheckActor(ctx context.Context, id int) error
+	// GetActor returns the actor along with the credited films or ErrNotFound.
+	GetActor(ctx context.Context, id int) (st.ActorResponse, error)
+	// AddActorFilm, UpdateActorFilm and DelActorFilm manage the credits of people in films,
+	// a credit is identified by the actor, the film and the credit type. Updating or deleting
+	// a missing credit returns ErrNotFound.
+	AddActorFilm(ctx context.Context, actorFilm st.ActorFilm) error
+	UpdateActorFilm(ctx context.Context, actorFilm st.ActorFilm) error
+	DelActorFilm(ctx context.Context, actorFilm st.ActorFilm) error
+	// GetFilmCredits returns the credits of the film ordered by credit type and billing.
+	GetFilmCredits(ctx context.Context, filmID int) ([]st.CreditResponse, error)
+	// GetFilmsActor returns a page of actors ordered by surname and name along with their credited films.
+	GetFilmsActor(ctx context.Context, q st.ActorListQuery) (st.ActorList, error)
+	// SearchActors runs the full-text search over the actor full names tolerating typos, the best matches first.
+	SearchActors(ctx context.Context, query string, limit, offset int) ([]st.ActorHit, error)
+}
+
+// GenreRepository describes the storage operations over genres and their links to films.
+type GenreRepository interface {
+	AddGenre(ctx context.Context, genre st.Genre) error
+	// UpdateGenre renames the genre, it returns ErrNotFound if there is no such genre.
+	UpdateGenre(ctx context.Context, genre st.Genre) error
+	DelGenre(ctx context.Context, id int) error
+	CheckGenre(ctx context.Context, id int) error
+	GetGenres(ctx context.Context) ([]st.Genre, error)
+	AddFilmGenre(ctx context.Context, filmGenre st.FilmGenre) error
+	DelFilmGenre(ctx context.Context, filmGenre st.FilmGenre) error
+}
+
+// ReviewRepository describes the storage operations over film reviews.
+// Every change of the reviews of a film recomputes its rating and votes.
+type ReviewRepository interface {
+	// UpsertReview creates the review of the user for the film or replaces its score and text.
+	// It returns ErrNotFound if there is no such film.
+	UpsertReview(ctx context.Context, review st.Review) error
+	// GetFilmReviews returns a page of the film reviews, the newest first.
+	GetFilmReviews(ctx context.Context, filmID, limit, offset int) (st.ReviewList, error)
+	// DelReview deletes the review with the given id, it returns ErrNotFound if there is none.
+	DelReview(ctx context.Context, id int) error
+	// DelUserReview deletes the review of the user for the film, it returns ErrNotFound if there is none.
+	DelUserReview(ctx context.Context, filmID int, login string) error
+}
+
+// WatchlistRepository describes the storage operations over the watchlists and the watch histories of users.
+type WatchlistRepository interface {
+	// AddToWatchlist returns ErrNotFound if there is no such film and ErrAlreadyExists if it is already listed.
+	AddToWatchlist(ctx context.Context, login string, filmID int) error
+	// RemoveFromWatchlist returns ErrNotFound if the film is not listed.
+	RemoveFromWatchlist(ctx context.Context, login string, filmID int) error
+	// GetWatchlist returns a page of the watchlist, the latest added first.
+	GetWatchlist(ctx context.Context, login string, limit, offset int) (st.Watchlist, error)
+	// MarkWatched records that the user watched the film on the date and takes it off the watchlist.
+	// Marking the same viewing twice is not an error. Invalid dates are reported with ErrInvalidWatch.
+	MarkWatched(ctx context.Context, login string, watched st.WatchedFilm) error
+	// UnmarkWatched deletes the viewing of the film on the date or, if the date is empty, every viewing of it.
+	// It returns ErrNotFound if nothing was deleted.
+	UnmarkWatched(ctx context.Context, login string, watched st.WatchedFilm) error
+	// GetWatchHistory returns a page of the viewings of the user, the latest first.
+	GetWatchHistory(ctx context.Context, login string, limit, offset int) (st.WatchHistory, error)
+}
+
+// CollectionRepository describes the storage operations over the film collections of users.
+// Collections are found by their slug and only their owner changes them: the changing operations
+// return ErrNotFound for collections of other users.
+type CollectionRepository interface {
+	// CreateCollection stores a new collection with no films, it returns ErrAlreadyExists if the slug is taken.
+	CreateCollection(ctx context.Context, collection st.Collection) error
+	// GetCollection returns the collection along with its films in order.
+	// Private collections are only returned to their owner, the others get ErrNotFound.
+	GetCollection(ctx context.Context, slug, viewer string) (st.Collection, error)
+	// ListPublicCollections returns a page of public collections, the latest updated first.
+	ListPublicCollections(ctx context.Context, q st.CollectionListQuery) (st.CollectionList, error)
+	// GetUserCollections returns a page of the public and private collections of the user, the latest updated first.
+	GetUserCollections(ctx context.Context, login string, limit, offset int) (st.CollectionList, error)
+	UpdateCollection(ctx context.Context, login, slug string, update st.CollectionUpdate) error
+	DelCollection(ctx context.Context, login, slug string) error
+	// AddCollectionFilm appends the film to the collection.
+	// It returns ErrNotFound if there is no such film and ErrAlreadyExists if it is already there.
+	AddCollectionFilm(ctx context.Context, login, slug string, filmID int) error
+	DelCollectionFilm(ctx context.Context, login, slug string, filmID int) error
+	// ReorderCollection puts the films of the collection in the given order, which must list each of them once.
+	ReorderCollection(ctx context.Context, login, slug string, filmIDs []int) error
+}
+
+// RecommendationRepository provides the data recommendations are computed from.
+type RecommendationRepository interface {
+	// GetRelatedFilms returns the features of the given films and of at most limit other films, ordered by id.
+	// The other films are the ones sharing the most with the given films: every credited person or genre
+	// shared with a given film counts its weight in package recommend times the weight of that film.
+	// Films scoring zero or less are left out.
+	GetRelatedFilms(ctx context.Context, filmIDs []int, weights []float64, limit int) ([]st.FilmFeatures, error)
+	// GetUserSignals returns the films the user rated or watched, ordered by film id.
+	GetUserSignals(ctx context.Context, login string) ([]st.UserSignal, error)
+}
+
+// RoleRepository describes the storage operations over roles and their permissions.
+type RoleRepository interface {
+	// GetRoles returns every role along with its permissions.
+	GetRoles(ctx context.Context) ([]st.Role, error)
+	// HasPermission reports whether the current role of the user grants the permission.
+	// Unknown users have no permissions.
+	HasPermission(ctx context.Context, login, permission string) (bool, error)
+	// SetUserRole assigns the role to the user, it returns ErrNotFound if either of them does not exist.
+	SetUserRole(ctx context.Context, login, role string) error
+}
+
+// SessionRepository describes the storage operations over login sessions and their refresh tokens.
+// Refresh tokens are stored hashed, the callers hash them.
+type SessionRepository interface {
+	// CreateSession stores a new session along with its first refresh token.
+	CreateSession(ctx context.Context, session st.Session, token st.RefreshToken) error
+	// RotateRefreshToken marks the refresh token as used and stores the next one in the same session.
+	// It returns ErrNotFound if the token is unknown, expired or its session is revoked, and
+	// ErrTokenReused, revoking the session, if the token was already used.
+	RotateRefreshToken(ctx context.Context, hash string, next st.RefreshToken) (st.Session, error)
+	// RevokeSession ends the session, its access and refresh tokens stop being accepted.
+	RevokeSession(ctx context.Context, id string) error
+	// RevokeUserSessions ends every session of the user except the one given, which may be empty.
+	RevokeUserSessions(ctx context.Context, login, exceptID string) error
+	// IsSessionRevoked reports whether the session was revoked, unknown sessions count as revoked.
+	IsSessionRevoked(ctx context.Context, id string) (bool, error)
+}
+
+// UserRepository describes the storage operations over users.
+// Passwords are stored exactly as given, hashing is the caller's concern.
+type UserRepository interface {
+	AddUser(ctx context.Context, u st.User) error
+	GetUser(ctx context.Context, login string) (st.User, error)
+	// UpdatePassword replaces the password hash of the user, it returns ErrNotFound if there is no such user.
+	UpdatePassword(ctx context.Context, login, password string) error
+	// GetProfile returns the profile of the user.
+	GetProfile(ctx context.Context, login string) (st.Profile, error)
+	// UpdateProfile changes the non-nil fields of the profile, it returns ErrAlreadyExists if the email is taken.
+	UpdateProfile(ctx context.Context, login string, update st.ProfileUpdate) error
+	// DelUser deletes the user along with the reviews, sessions and other data of the user,
+	// the ratings of the reviewed films are recomputed.
+	DelUser(ctx context.Context, login string) error
+	// ListUsers returns a page of users ordered by login whose login, display name or email contain the query.
+	ListUsers(ctx context.Context, q st.UserListQuery) (st.UserList, error)
+	// SetUserDisabled disables or re-enables the user, disabled users can not log in.
+	SetUserDisabled(ctx context.Context, login string, disabled bool) error
+}
+
+// PasswordResetRepository describes the storage operations over password reset tokens.
+// Tokens are stored hashed, the callers hash them.
+type PasswordResetRepository interface {
+	// CreatePasswordReset stores a reset token of the user, replacing the earlier ones.
+	CreatePasswordReset(ctx context.Context, hash, login string, expiresAt time.Time) error
+	// GetPasswordResetLogin returns the login of the user of a reset token that can still be used,
+	// ErrNotFound if the token is unknown, expired or already used.
+	GetPasswordResetLogin(ctx context.Context, hash string) (string, error)
+	// ResetPassword uses up the reset token and replaces the password hash of its user, whose login it returns.
+	// It returns ErrNotFound if the token is unknown, expired or already used.
+	ResetPassword(ctx context.Context, hash, password string) (string, error)
+}
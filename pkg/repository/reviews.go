@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	st "VK_app/internal/structures"
+)
+
+const (
+	// MinScore and MaxScore bound the score of a review.
+	MinScore = 1
+	MaxScore = 10
+	// MaxReviewText is the longest review text that can be stored.
+	MaxReviewText = 2000
+)
+
+// ErrInvalidReview is returned when a review has a score out of range or a too long text.
+var ErrInvalidReview = errors.New("invalid review")
+
+// ValidateReview checks the score and the text of the review.
+func ValidateReview(review st.Review) error {
+	if review.Score < MinScore || review.Score > MaxScore {
+		return fmt.Errorf("%w: score must be between %d and %d", ErrInvalidReview, MinScore, MaxScore)
+	}
+	if len([]rune(review.Text)) > MaxReviewText {
+		return fmt.Errorf("%w: text is longer than %d characters", ErrInvalidReview, MaxReviewText)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package repository
+
+// Permissions granted to roles.
+const (
+	// PermFilmsRead allows reading the catalogue: films, actors, genres and reviews.
+	PermFilmsRead = "films:read"
+	// PermReviewsWrite allows users to rate films and manage their own reviews.
+	PermReviewsWrite = "reviews:write"
+	// PermCatalogWrite allows creating, updating and deleting films, actors, genres and credits.
+	PermCatalogWrite = "catalog:write"
+	// PermReviewsModerate allows removing any review.
+	PermReviewsModerate = "reviews:moderate"
+	// PermUsersManage allows assigning roles to users.
+	PermUsersManage = "users:manage"
+)
+
+// Roles known to the library.
+const (
+	RoleViewer    = "viewer"
+	RoleEditor    = "editor"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// DefaultRole is the role of newly registered users.
+const DefaultRole = RoleViewer
+
+// DefaultRolePermissions is the role model created by the 0007_roles migration.
+var DefaultRolePermissions = map[string][]string{
+	RoleViewer:    {PermFilmsRead, PermReviewsWrite},
+	RoleEditor:    {PermFilmsRead, PermReviewsWrite, PermCatalogWrite},
+	RoleModerator: {PermFilmsRead, PermReviewsWrite, PermReviewsModerate},
+	RoleAdmin:     {PermFilmsRead, PermReviewsWrite, PermCatalogWrite, PermReviewsModerate, PermUsersManage},
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// FuzzyThreshold is the minimal trigram word similarity for a typo to still match.
+	FuzzyThreshold = 0.3
+	// MaxSearchQuery is the longest search query accepted.
+	MaxSearchQuery = 200
+	// HighlightStart and HighlightStop wrap the matched words in search snippets.
+	HighlightStart = "<b>"
+	HighlightStop  = "</b>"
+)
+
+// NormalizeSearch trims the search query and validates it along with the page.
+func NormalizeSearch(query *string, limit *int, offset int) error {
+	*query = strings.TrimSpace(*query)
+	if *query == "" {
+		return fmt.Errorf("%w: search query is empty", ErrInvalidQuery)
+	}
+	if utf8.RuneCountInString(*query) > MaxSearchQuery {
+		return fmt.Errorf("%w: search query is longer than %d characters", ErrInvalidQuery, MaxSearchQuery)
+	}
+	return NormalizePage(limit, offset)
+}
@@ -0,0 +1,7 @@
+package repository
+
+import "errors"
+
+// ErrTokenReused is returned when an already rotated refresh token is presented again,
+// which means it was stolen either from the user or from the attacker.
+var ErrTokenReused = errors.New("refresh token reused")
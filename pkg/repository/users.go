@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	st "VK_app/internal/structures"
+)
+
+const (
+	// MaxDisplayName is the longest display name that can be stored.
+	MaxDisplayName = 100
+	// MaxEmail is the longest email that can be stored.
+	MaxEmail = 254
+	// DefaultLanguage is the language of new users.
+	DefaultLanguage = "ru"
+)
+
+// Languages are the supported interface languages.
+var Languages = map[string]bool{
+	"ru": true,
+	"en": true,
+}
+
+// ErrInvalidProfile is returned when a profile update has a malformed email, an unsupported language or a too long name.
+var ErrInvalidProfile = errors.New("invalid profile")
+
+// NormalizeProfileUpdate validates the changed fields of a profile and lowercases the email,
+// so that emails are unique regardless of case. An empty email or display name clears it.
+func NormalizeProfileUpdate(update *st.ProfileUpdate) error {
+	if update.DisplayName != nil && len([]rune(*update.DisplayName)) > MaxDisplayName {
+		return fmt.Errorf("%w: display name is longer than %d characters", ErrInvalidProfile, MaxDisplayName)
+	}
+	if update.Email != nil && *update.Email != "" {
+		address, err := mail.ParseAddress(*update.Email)
+		if err != nil || address.Address != *update.Email || len(*update.Email) > MaxEmail {
+			return fmt.Errorf("%w: email is malformed", ErrInvalidProfile)
+		}
+		email := strings.ToLower(*update.Email)
+		update.Email = &email
+	}
+	if update.Language != nil && !Languages[*update.Language] {
+		return fmt.Errorf("%w: language must be ru or en", ErrInvalidProfile)
+	}
+	return nil
+}
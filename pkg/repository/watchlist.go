@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	st "VK_app/internal/structures"
+)
+
+// DateLayout is the layout of the dates of films and viewings.
+const DateLayout = "20060102"
+
+// ErrInvalidWatch is returned when a film is marked as watched on a malformed or future date.
+var ErrInvalidWatch = errors.New("invalid viewing")
+
+// NormalizeWatched defaults the date of the viewing to today and checks it is a real date not after today.
+func NormalizeWatched(watched *st.WatchedFilm, now time.Time) error {
+	today := now.Format(DateLayout)
+	if watched.Date == "" {
+		watched.Date = today
+		return nil
+	}
+	if _, err := time.Parse(DateLayout, watched.Date); err != nil {
+		return fmt.Errorf("%w: date must be a real date in the YYYYMMDD format", ErrInvalidWatch)
+	}
+	if watched.Date > today {
+		return fmt.Errorf("%w: date is in the future", ErrInvalidWatch)
+	}
+	return nil
+}